@@ -1,8 +1,10 @@
 package automerge
 
 import (
+	"bytes"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"sync"
 
@@ -56,3 +58,42 @@ func makeBloomFilter(options bloomOptions, page *Page) (*bloom.BloomFilter, erro
 	}
 	return filter, nil
 }
+
+// maxBloomK bounds the number of hash functions accepted from an encoded bloom filter
+const maxBloomK = 64
+
+// decodeBloomFilter decodes a bloom filter written by BloomFilter.WriteTo.  As data may
+// come from a peer or a corrupt stream, the header is checked against the length of data
+// before the bitset is allocated.
+func decodeBloomFilter(data []byte) (*bloom.BloomFilter, error) {
+	const headerSize = 24 // m, k and the bitset length as big endian uint64s
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("unable to decode bloom filter: %w", io.ErrUnexpectedEOF)
+	}
+
+	var (
+		m      = binary.BigEndian.Uint64(data)
+		k      = binary.BigEndian.Uint64(data[8:])
+		length = binary.BigEndian.Uint64(data[16:])
+		words  = length / 64
+	)
+	if length%64 != 0 {
+		words++
+	}
+	switch {
+	case m == 0:
+		return nil, fmt.Errorf("unable to decode bloom filter: invalid m, %v", m)
+	case k == 0 || k > maxBloomK:
+		return nil, fmt.Errorf("unable to decode bloom filter: invalid k, %v", k)
+	case length != m:
+		return nil, fmt.Errorf("unable to decode bloom filter: bitset length, %v, does not match m, %v", length, m)
+	case words != uint64(len(data)-headerSize)/8 || (len(data)-headerSize)%8 != 0:
+		return nil, fmt.Errorf("unable to decode bloom filter: %w", io.ErrUnexpectedEOF)
+	}
+
+	filter := &bloom.BloomFilter{}
+	if _, err := filter.ReadFrom(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("unable to decode bloom filter: %w", err)
+	}
+	return filter, nil
+}
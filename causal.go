@@ -0,0 +1,127 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"bytes"
+	"sort"
+)
+
+// causalQueue holds ops and changes that arrived before their prerequisites.  Each is
+// keyed by a single missing prerequisite; once released, an entry is retried and, if
+// another prerequisite is still missing, queued again under that one.
+type causalQueue struct {
+	ops     map[string][]Op    // ops by the ID.String() of the op they wait on
+	missing map[string]ID      // ids waiting to be applied by ID.String()
+	changes map[Hash][]*Change // changes by the hash of the dependency they wait on
+	queued  map[Hash]struct{}  // hashes of the changes held
+}
+
+func newCausalQueue() *causalQueue {
+	return &causalQueue{
+		ops:     map[string][]Op{},
+		missing: map[string]ID{},
+		changes: map[Hash][]*Change{},
+		queued:  map[Hash]struct{}{},
+	}
+}
+
+// addOp holds op until the op identified by id is applied
+func (q *causalQueue) addOp(id ID, op Op) {
+	key := id.String()
+	q.ops[key] = append(q.ops[key], op)
+	q.missing[key] = id
+}
+
+// releaseOps removes and returns the ops waiting on id
+func (q *causalQueue) releaseOps(id ID) []Op {
+	key := id.String()
+	ops := q.ops[key]
+	delete(q.ops, key)
+	delete(q.missing, key)
+	return ops
+}
+
+// addChange holds change, whose hash is hash, until the change identified by dep is
+// applied.  addChange returns false if change is already held.
+func (q *causalQueue) addChange(dep, hash Hash, change *Change) bool {
+	if _, ok := q.queued[hash]; ok {
+		return false
+	}
+	q.queued[hash] = struct{}{}
+	q.changes[dep] = append(q.changes[dep], change)
+	return true
+}
+
+// releaseChanges removes and returns the changes waiting on dep
+func (q *causalQueue) releaseChanges(dep Hash) ([]*Change, error) {
+	changes := q.changes[dep]
+	delete(q.changes, dep)
+	for _, change := range changes {
+		hash, err := change.Hash()
+		if err != nil {
+			return nil, err
+		}
+		delete(q.queued, hash)
+	}
+	return changes, nil
+}
+
+// Ops returns the ops held ordered by id
+func (q *causalQueue) Ops() []Op {
+	var ops []Op
+	for _, v := range q.ops {
+		ops = append(ops, v...)
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].ID.Compare(ops[j].ID) < 0
+	})
+	return ops
+}
+
+// Changes returns the changes held ordered by actor then seq
+func (q *causalQueue) Changes() []*Change {
+	var changes []*Change
+	for _, v := range q.changes {
+		changes = append(changes, v...)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		if c := bytes.Compare(changes[i].Actor, changes[j].Actor); c != 0 {
+			return c < 0
+		}
+		return changes[i].Seq < changes[j].Seq
+	})
+	return changes
+}
+
+// Missing returns the ids and hashes the held ops and changes are waiting on
+func (q *causalQueue) Missing() ([]ID, []Hash) {
+	var ids []ID
+	for _, id := range q.missing {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].Compare(ids[j]) < 0
+	})
+
+	var hashes []Hash
+	for hash := range q.changes {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+	return ids, hashes
+}
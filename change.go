@@ -0,0 +1,267 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+)
+
+// changeVersion identifies the format written by Change.MarshalBinary
+const changeVersion byte = 1
+
+// Hash identifies a change by the sha256 of its binary encoding
+type Hash [sha256.Size]byte
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Change groups the ops made together by a single actor.  Seq numbers the changes of
+// an actor starting from 1 and Deps holds the hashes of the changes that were the heads
+// of the document when the change was made.
+type Change struct {
+	Actor   []byte
+	Seq     int64
+	StartOp int64 // StartOp holds the counter of the first op
+	Deps    []Hash
+	Time    time.Time // Time is encoded with millisecond precision
+	Message string
+	Ops     []Op
+}
+
+// Hash returns the sha256 of the binary encoding of the change
+func (c *Change) Hash() (Hash, error) {
+	data, err := c.MarshalBinary()
+	if err != nil {
+		return Hash{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// MarshalBinary encodes the change as:
+// * version byte
+// * var int actor length and actor
+// * var int seq, var int start op
+// * var int dep count followed by each dep hash
+// * var int time in unix milliseconds
+// * var int message length and message
+// * var int op count followed by each op
+//
+// Each op is encoded as its obj, id, and ref, each a var int counter and var int actor
+// length and actor, followed by the var int op type and the value encoded by appendValue.
+func (c *Change) MarshalBinary() ([]byte, error) {
+	var ms int64
+	if !c.Time.IsZero() {
+		ms = c.Time.Unix()*1000 + int64(c.Time.Nanosecond())/int64(time.Millisecond)
+	}
+
+	data := []byte{changeVersion}
+	data = appendBytes(data, c.Actor)
+	data = appendVarint(data, c.Seq)
+	data = appendVarint(data, c.StartOp)
+	data = appendUvarint(data, uint64(len(c.Deps)))
+	for _, dep := range c.Deps {
+		data = append(data, dep[:]...)
+	}
+	data = appendVarint(data, ms)
+	data = appendBytes(data, []byte(c.Message))
+	data = appendUvarint(data, uint64(len(c.Ops)))
+
+	var err error
+	for i, op := range c.Ops {
+		data, err = appendOp(data, op)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal op, %v: %w", i, err)
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a change encoded by MarshalBinary
+func (c *Change) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("unable to read change header: %w", io.ErrUnexpectedEOF)
+	}
+	if version := data[0]; version != changeVersion {
+		return fmt.Errorf("unable to read change: unsupported version, %v", version)
+	}
+	r := changeReader{data: data[1:]}
+
+	var change Change
+	change.Actor = r.bytes()
+	change.Seq = r.varint()
+	change.StartOp = r.varint()
+	change.Deps = r.hashes()
+	if ms := r.varint(); ms != 0 {
+		change.Time = time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+	}
+	change.Message = string(r.bytes())
+
+	n := r.uvarint()
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		op := r.op()
+		if r.err != nil {
+			return fmt.Errorf("unable to read change op, %v: %w", i, r.err)
+		}
+		change.Ops = append(change.Ops, op)
+	}
+	if r.err != nil {
+		return fmt.Errorf("unable to read change: %w", r.err)
+	}
+
+	*c = change
+	return nil
+}
+
+// changeReader decodes the fields of a change or sync message, retaining the first error
+// encountered
+type changeReader struct {
+	data []byte
+	err  error
+}
+
+func (r *changeReader) next(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.data) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := r.data[:n]
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *changeReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *changeReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *changeReader) bytes() []byte {
+	n := r.uvarint()
+	if n > uint64(len(r.data)) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := r.next(int(n))
+	if len(v) == 0 {
+		return nil
+	}
+	return append([]byte(nil), v...)
+}
+
+// hashes decodes a var int count followed by each hash
+func (r *changeReader) hashes() []Hash {
+	n := r.uvarint()
+	if n == 0 || r.err != nil {
+		return nil
+	}
+	if n > uint64(len(r.data))/uint64(len(Hash{})) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+
+	hashes := make([]Hash, n)
+	for i := range hashes {
+		copy(hashes[i][:], r.next(len(hashes[i])))
+	}
+	return hashes
+}
+
+func (r *changeReader) id() ID {
+	counter := r.varint()
+	return NewID(counter, r.bytes())
+}
+
+// op decodes an op encoded by appendOp
+func (r *changeReader) op() Op {
+	op := Op{
+		Obj:  r.id(),
+		ID:   r.id(),
+		Ref:  r.id(),
+		Type: r.varint(),
+	}
+	if r.err != nil {
+		return Op{}
+	}
+
+	value, n, err := readValue(r.data)
+	if err != nil {
+		r.err = err
+		return Op{}
+	}
+	r.data = r.data[n:]
+	op.Value = value
+	return op
+}
+
+func appendVarint(data []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], v)
+	return append(data, buf[:n]...)
+}
+
+func appendUvarint(data []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(data, buf[:n]...)
+}
+
+func appendBytes(data, v []byte) []byte {
+	data = appendUvarint(data, uint64(len(v)))
+	return append(data, v...)
+}
+
+func appendID(data []byte, id ID) []byte {
+	data = appendVarint(data, id.Counter)
+	return appendBytes(data, id.Actor)
+}
+
+// appendOp encodes the obj, id, and ref of op followed by the var int op type and the
+// value encoded by appendValue
+func appendOp(data []byte, op Op) ([]byte, error) {
+	data = appendID(data, op.Obj)
+	data = appendID(data, op.ID)
+	data = appendID(data, op.Ref)
+	data = appendVarint(data, op.Type)
+	return appendValue(data, op.Value)
+}
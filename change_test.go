@@ -0,0 +1,133 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+func TestChange_MarshalBinary(t *testing.T) {
+	actor := []byte("me")
+	want := &Change{
+		Actor:   actor,
+		Seq:     2,
+		StartOp: 5,
+		Deps:    []Hash{{1}, {2}},
+		Time:    time.Unix(1600000000, int64(123*time.Millisecond)),
+		Message: "hello",
+		Ops: []Op{
+			{ID: NewID(5, actor), Type: MapMakeText, Value: encoding.ByteSliceValue([]byte("text"))},
+			{ID: NewID(6, actor), Obj: NewID(5, actor), Type: TextInsert, Value: encoding.RuneValue('a')},
+			{ID: NewID(7, actor), Obj: NewID(5, actor), Ref: NewID(6, actor), Type: TextDelete},
+		},
+	}
+
+	data, err := want.MarshalBinary()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	got := &Change{}
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if !got.Time.Equal(want.Time) {
+		t.Fatalf("got %v, want %v", got.Time, want.Time)
+	}
+	if !reflect.DeepEqual(got.Deps, want.Deps) {
+		t.Fatalf("got %v, want %v", got.Deps, want.Deps)
+	}
+	if got.Seq != want.Seq || got.StartOp != want.StartOp || got.Message != want.Message || !bytes.Equal(got.Actor, want.Actor) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+	if want, got := len(want.Ops), len(got.Ops); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, op := range got.Ops {
+		w := want.Ops[i]
+		if !op.ID.Equal(w.ID) || !op.Ref.Equal(w.Ref) || !op.Obj.Equal(w.Obj) || op.Type != w.Type {
+			t.Fatalf("got %v, want %v", op, w)
+		}
+		if op.Value.RawType != w.Value.RawType || op.Value.Int != w.Value.Int || !bytes.Equal(op.Value.Bytes, w.Value.Bytes) {
+			t.Fatalf("got %v, want %v", op.Value, w.Value)
+		}
+	}
+
+	t.Run("hash", func(t *testing.T) {
+		h1, err := want.Hash()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		h2, err := got.Hash()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if h1 != h2 {
+			t.Fatalf("got %v, want %v", h2, h1)
+		}
+
+		got.Message = "changed"
+		h3, err := got.Hash()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if h1 == h3 {
+			t.Fatalf("got %v; want different hash", h3)
+		}
+	})
+
+	t.Run("far dates", func(t *testing.T) {
+		for _, tm := range []time.Time{
+			time.Date(1500, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2500, 1, 1, 0, 0, 0, 123e6, time.UTC),
+		} {
+			change := &Change{Actor: actor, Seq: 1, Time: tm}
+			data, err := change.MarshalBinary()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			got := &Change{}
+			if err := got.UnmarshalBinary(data); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !got.Time.Equal(tm) {
+				t.Fatalf("got %v, want %v", got.Time, tm)
+			}
+
+			// deps on the change must resolve once it is decoded
+			h1, _ := change.Hash()
+			h2, _ := got.Hash()
+			if h1 != h2 {
+				t.Fatalf("got %v, want %v", h2, h1)
+			}
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		for i := 1; i < len(data); i++ {
+			if err := (&Change{}).UnmarshalBinary(data[:i]); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("got %v; want %v at %v", err, io.ErrUnexpectedEOF, i)
+			}
+		}
+	})
+}
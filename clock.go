@@ -0,0 +1,134 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"errors"
+	"io"
+)
+
+// ClockOrder describes how two clocks relate to one another
+type ClockOrder int
+
+const (
+	ClockEqual      ClockOrder = iota // clocks hold the same counters
+	ClockBefore                       // receiver is strictly behind
+	ClockAfter                        // receiver is strictly ahead
+	ClockConcurrent                   // each clock holds counters the other lacks
+)
+
+func (c ClockOrder) String() string {
+	switch c {
+	case ClockEqual:
+		return "equal"
+	case ClockBefore:
+		return "before"
+	case ClockAfter:
+		return "after"
+	default:
+		return "concurrent"
+	}
+}
+
+// Clock holds the largest counter seen from each actor keyed by string(actor)
+type Clock map[string]int64
+
+// Observe records id within the clock
+func (c Clock) Observe(id ID) {
+	key := string(id.Actor)
+	if id.Counter > c[key] {
+		c[key] = id.Counter
+	}
+}
+
+// Covers returns true if the clock has seen a counter from id.Actor at least as large
+// as id.Counter
+func (c Clock) Covers(id ID) bool {
+	return c[string(id.Actor)] >= id.Counter
+}
+
+// Max returns the largest counter seen from any actor.  The next local op should be
+// allocated a counter greater than Max.
+func (c Clock) Max() int64 {
+	var max int64
+	for _, counter := range c {
+		if counter > max {
+			max = counter
+		}
+	}
+	return max
+}
+
+// Copy returns a copy of the clock
+func (c Clock) Copy() Clock {
+	v := make(Clock, len(c))
+	for actor, counter := range c {
+		v[actor] = counter
+	}
+	return v
+}
+
+// Merge returns a new clock holding the larger counter for each actor in either clock
+func (c Clock) Merge(that Clock) Clock {
+	v := c.Copy()
+	for actor, counter := range that {
+		if counter > v[actor] {
+			v[actor] = counter
+		}
+	}
+	return v
+}
+
+// Compare returns how c relates to that
+func (c Clock) Compare(that Clock) ClockOrder {
+	var ahead, behind bool
+	for actor, counter := range c {
+		if counter > that[actor] {
+			ahead = true
+		}
+	}
+	for actor, counter := range that {
+		if counter > c[actor] {
+			behind = true
+		}
+	}
+
+	switch {
+	case ahead && behind:
+		return ClockConcurrent
+	case ahead:
+		return ClockAfter
+	case behind:
+		return ClockBefore
+	default:
+		return ClockEqual
+	}
+}
+
+// observePage records the id of every op within page
+func (c Clock) observePage(page *Page) error {
+	var token IDToken
+	var err error
+	for {
+		token, err = page.NextID(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		c.Observe(NewID(token.Counter, token.Actor))
+	}
+}
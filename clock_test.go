@@ -0,0 +1,125 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestClock(t *testing.T) {
+	a, b := []byte("a"), []byte("b")
+
+	clock := Clock{}
+	clock.Observe(NewID(3, a))
+	clock.Observe(NewID(1, a))
+	clock.Observe(NewID(2, b))
+
+	t.Run("covers", func(t *testing.T) {
+		testCases := map[string]struct {
+			ID   ID
+			Want bool
+		}{
+			"seen":     {ID: NewID(3, a), Want: true},
+			"earlier":  {ID: NewID(1, b), Want: true},
+			"later":    {ID: NewID(4, a), Want: false},
+			"unknown":  {ID: NewID(1, []byte("c")), Want: false},
+			"doc-root": {ID: ID{}, Want: true},
+		}
+		for label, tc := range testCases {
+			t.Run(label, func(t *testing.T) {
+				if got := clock.Covers(tc.ID); got != tc.Want {
+					t.Fatalf("got %v, want %v", got, tc.Want)
+				}
+			})
+		}
+	})
+
+	t.Run("max", func(t *testing.T) {
+		if want, got := int64(3), clock.Max(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("compare", func(t *testing.T) {
+		testCases := map[string]struct {
+			That Clock
+			Want ClockOrder
+		}{
+			"equal":      {That: Clock{"a": 3, "b": 2}, Want: ClockEqual},
+			"before":     {That: Clock{"a": 3, "b": 2, "c": 1}, Want: ClockBefore},
+			"after":      {That: Clock{"a": 2}, Want: ClockAfter},
+			"concurrent": {That: Clock{"a": 4, "b": 1}, Want: ClockConcurrent},
+		}
+		for label, tc := range testCases {
+			t.Run(label, func(t *testing.T) {
+				if got := clock.Compare(tc.That); got != tc.Want {
+					t.Fatalf("got %v, want %v", got, tc.Want)
+				}
+			})
+		}
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		got := clock.Merge(Clock{"a": 2, "c": 5})
+		if want := (Clock{"a": 3, "b": 2, "c": 5}); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if _, ok := clock["c"]; ok {
+			t.Fatalf("got true; want merge to leave receiver unchanged")
+		}
+	})
+}
+
+func TestObject_Clock(t *testing.T) {
+	a := NewText([]byte("a"), WithMaxPageSize(4))
+	b := NewText([]byte("b"), WithMaxPageSize(4))
+	if err := a.InsertAt(0, "hello"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.obj.Merge(a.obj); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	b.clock.Observe(a.clock.counter)
+	if err := b.InsertAt(0, "!"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	want := Clock{"a": 5, "b": 6}
+	if got := b.obj.Clock(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if !b.obj.Covers(NewID(5, []byte("a"))) {
+		t.Fatalf("got false; want true")
+	}
+	if want, got := ClockBefore, a.obj.Clock().Compare(b.obj.Clock()); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Run("read", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		if _, err := b.obj.WriteTo(buf); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		obj, err := ReadObject(buf, WithMaxPageSize(4), WithDeleteFunc(isSequenceDelete))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got := obj.Clock(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
@@ -0,0 +1,526 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+// RootID identifies the root map of a Document
+var RootID = ID{}
+
+// ErrObjectNotFound indicates an op targets an object the document does not hold
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectType identifies the kind of object held by a Document
+type ObjectType int
+
+const (
+	ObjectTypeUnknown ObjectType = iota
+	ObjectTypeMap
+	ObjectTypeList
+	ObjectTypeText
+)
+
+func (o ObjectType) String() string {
+	switch o {
+	case ObjectTypeMap:
+		return "map"
+	case ObjectTypeList:
+		return "list"
+	case ObjectTypeText:
+		return "text"
+	default:
+		return "unknown"
+	}
+}
+
+// objectType returns the type of object created by a map op of the given type
+func objectType(opType int64) ObjectType {
+	switch opType {
+	case MapMakeMap:
+		return ObjectTypeMap
+	case MapMakeList:
+		return ObjectTypeList
+	case MapMakeText:
+		return ObjectTypeText
+	default:
+		return ObjectTypeUnknown
+	}
+}
+
+// Document is a tree of objects rooted at a Map.  Nested objects are created by assigning
+// them to a key of a map and are identified by the id of the op that created them.  All
+// objects share the document's actor and lamport clock.
+//
+// Local edits accumulate until Commit bundles them into a Change.  Changes from other
+// replicas are applied with ApplyChange.  Remote ops and changes that arrive before
+// their prerequisites are held until the prerequisites have been applied.
+type Document struct {
+	clock   *lamport
+	opts    []ObjectOption
+	root    *Map
+	objects map[string]interface{} // objects by ID.String(); *Map, *List, or *Text
+
+	seq     int64            // seq of the last local change
+	pending []Op             // local ops not yet committed
+	heads   []Hash           // changes no other change depends on
+	changes map[Hash]*Change // changes applied, local and remote
+	history []*Change        // changes in the order applied
+	queue   *causalQueue     // remote ops and changes waiting on prerequisites
+}
+
+// NewDocument returns an empty document whose local edits are attributed to actor.  opts
+// are applied to every object within the document.
+func NewDocument(actor []byte, opts ...ObjectOption) *Document {
+	clock := newLamport(actor)
+	doc := &Document{
+		clock:   clock,
+		opts:    opts,
+		objects: map[string]interface{}{},
+		changes: map[Hash]*Change{},
+		queue:   newCausalQueue(),
+	}
+	doc.root = doc.create(RootID, ObjectTypeMap).(*Map)
+	return doc
+}
+
+func (d *Document) record(op Op) {
+	d.pending = append(d.pending, op)
+}
+
+// Root returns the root map
+func (d *Document) Root() *Map {
+	return d.root
+}
+
+// Apply applies an op, local or remote, to the object identified by op.Obj.  Ops whose
+// object or ref has not yet been applied are held and applied once it has.
+func (d *Document) Apply(op Op) error {
+	ready := []Op{op}
+	for len(ready) > 0 {
+		op, ready = ready[0], ready[1:]
+
+		if err := d.apply(op); err != nil {
+			switch {
+			case errors.Is(err, ErrObjectNotFound):
+				d.queue.addOp(op.Obj, op)
+				continue
+			case errors.Is(err, ErrRefNotFound):
+				d.queue.addOp(op.Ref, op)
+				continue
+			default:
+				return err
+			}
+		}
+
+		ready = append(ready, d.queue.releaseOps(op.ID)...)
+	}
+	return nil
+}
+
+func (d *Document) apply(op Op) error {
+	v := d.objects[op.Obj.String()]
+	if obj := objectOf(v); obj != nil {
+		// ops may be delivered more than once, e.g. by both Sync and Merge
+		if ok, err := obj.contains(op.ID); err != nil {
+			return fmt.Errorf("unable to apply op (%v,%v): %w", op.ID.Counter, op.ID.Actor, err)
+		} else if ok {
+			return nil
+		}
+	}
+
+	switch v := v.(type) {
+	case *Map:
+		if err := v.Apply(op); err != nil {
+			return err
+		}
+		if t := objectType(op.Type); t != ObjectTypeUnknown {
+			if _, ok := d.objects[op.ID.String()]; !ok {
+				d.create(op.ID, t)
+			}
+		}
+		return nil
+	case *List:
+		return v.Apply(op)
+	case *Text:
+		return v.Apply(op)
+	default:
+		return fmt.Errorf("unable to apply op (%v,%v) to object %v: %w", op.ID.Counter, op.ID.Actor, op.Obj, ErrObjectNotFound)
+	}
+}
+
+// create registers a new, empty object of type t with the given id
+func (d *Document) create(id ID, t ObjectType) interface{} {
+	var v interface{}
+	switch t {
+	case ObjectTypeMap:
+		m := newMap(d.clock, id, d.opts...)
+		m.record = d.record
+		v = m
+	case ObjectTypeList:
+		l := newList(d.clock, id, d.opts...)
+		l.record = d.record
+		v = l
+	case ObjectTypeText:
+		text := newText(d.clock, id, d.opts...)
+		text.record = d.record
+		v = text
+	}
+	d.objects[id.String()] = v
+	return v
+}
+
+// makeObject assigns a new object, created by a map op of opType, to key in the map identified by parent
+func (d *Document) makeObject(parent ID, key string, opType int64) (interface{}, error) {
+	m, ok := d.Map(parent)
+	if !ok {
+		return nil, fmt.Errorf("unable to create object at key, %v: map %v not found", key, parent)
+	}
+
+	id, err := m.assign(key, opType, encoding.Value{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to create object at key, %v: %w", key, err)
+	}
+	return d.create(id, objectType(opType)), nil
+}
+
+// NewMap assigns a new Map to key within the map identified by parent
+func (d *Document) NewMap(parent ID, key string) (*Map, error) {
+	v, err := d.makeObject(parent, key, MapMakeMap)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Map), nil
+}
+
+// NewList assigns a new List to key within the map identified by parent
+func (d *Document) NewList(parent ID, key string) (*List, error) {
+	v, err := d.makeObject(parent, key, MapMakeList)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*List), nil
+}
+
+// NewText assigns a new Text to key within the map identified by parent
+func (d *Document) NewText(parent ID, key string) (*Text, error) {
+	v, err := d.makeObject(parent, key, MapMakeText)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*Text), nil
+}
+
+// Map returns the map identified by id
+func (d *Document) Map(id ID) (*Map, bool) {
+	m, ok := d.objects[id.String()].(*Map)
+	return m, ok
+}
+
+// List returns the list identified by id
+func (d *Document) List(id ID) (*List, bool) {
+	l, ok := d.objects[id.String()].(*List)
+	return l, ok
+}
+
+// Text returns the text identified by id
+func (d *Document) Text(id ID) (*Text, bool) {
+	t, ok := d.objects[id.String()].(*Text)
+	return t, ok
+}
+
+// Clock returns the largest counter applied from each actor across all objects
+func (d *Document) Clock() Clock {
+	clock := Clock{}
+	for _, v := range d.objects {
+		clock = clock.Merge(objectOf(v).clock)
+	}
+	return clock
+}
+
+// objectOf returns the Object backing a *Map, *List, or *Text
+func objectOf(v interface{}) *Object {
+	switch v := v.(type) {
+	case *Map:
+		return v.obj
+	case *List:
+		return v.obj
+	case *Text:
+		return v.obj
+	default:
+		return nil
+	}
+}
+
+// Lookup returns the id and type of the object currently assigned to key within the map
+// identified by parent
+func (d *Document) Lookup(parent ID, key string) (ID, ObjectType, bool) {
+	m, ok := d.Map(parent)
+	if !ok {
+		return ID{}, ObjectTypeUnknown, false
+	}
+
+	entry, ok := m.entry(key)
+	if !ok {
+		return ID{}, ObjectTypeUnknown, false
+	}
+
+	t := objectType(entry.Type)
+	if t == ObjectTypeUnknown {
+		return ID{}, ObjectTypeUnknown, false
+	}
+	return entry.ID, t, true
+}
+
+// Commit bundles the local ops made since the last commit into a Change that depends on
+// the current heads.  Commit returns nil when there are no local ops to commit.
+func (d *Document) Commit(message string) (*Change, error) {
+	if len(d.pending) == 0 {
+		return nil, nil
+	}
+
+	change := &Change{
+		Actor:   d.clock.actor,
+		Seq:     d.seq + 1,
+		StartOp: d.pending[0].ID.Counter,
+		Deps:    d.Heads(),
+		Time:    time.Now().Truncate(time.Millisecond),
+		Message: message,
+		Ops:     d.pending,
+	}
+	hash, err := change.Hash()
+	if err != nil {
+		return nil, fmt.Errorf("unable to commit change: %w", err)
+	}
+
+	d.seq = change.Seq
+	d.pending = nil
+	d.addChange(hash, change)
+	return change, nil
+}
+
+// ApplyChange applies a change received from another replica.  Changes already applied
+// are ignored and changes whose dependencies have not been applied are held until they
+// have been.  A change is applied in full or, if any of its ops cannot be applied, not
+// at all.
+func (d *Document) ApplyChange(change *Change) error {
+	ready := []*Change{change}
+	for len(ready) > 0 {
+		change, ready = ready[0], ready[1:]
+
+		hash, err := change.Hash()
+		if err != nil {
+			return fmt.Errorf("unable to apply change: %w", err)
+		}
+		if _, ok := d.changes[hash]; ok {
+			continue
+		}
+		if dep, ok := d.missingDep(change); ok {
+			d.queue.addChange(dep, hash, change)
+			continue
+		}
+		if err := d.validateChange(change); err != nil {
+			return fmt.Errorf("unable to apply change, %v: %w", hash, err)
+		}
+
+		for _, op := range change.Ops {
+			if err := d.Apply(op); err != nil {
+				return fmt.Errorf("unable to apply change, %v: %w", hash, err)
+			}
+		}
+		d.addChange(hash, change)
+
+		released, err := d.queue.releaseChanges(hash)
+		if err != nil {
+			return fmt.Errorf("unable to apply changes waiting on %v: %w", hash, err)
+		}
+		ready = append(ready, released...)
+	}
+	return nil
+}
+
+// Merge applies the changes applied to other, including those other is holding, that d
+// has not applied.  Ops other has applied outside of any change, its uncommitted local
+// ops and remote ops applied with Apply, are then merged individually.
+func (d *Document) Merge(other *Document) error {
+	_, pending := other.Pending()
+	for _, changes := range [][]*Change{other.history, pending} {
+		for _, change := range changes {
+			if err := d.ApplyChange(change); err != nil {
+				return fmt.Errorf("unable to merge document: %w", err)
+			}
+		}
+	}
+
+	ops, err := other.ops()
+	if err != nil {
+		return fmt.Errorf("unable to merge document: %w", err)
+	}
+	for _, op := range ops {
+		if err := d.Apply(op); err != nil {
+			return fmt.Errorf("unable to merge document: %w", err)
+		}
+	}
+	return nil
+}
+
+// ops returns a copy of every op applied to the objects of the document ordered by id;
+// lamport order is a valid causal order
+func (d *Document) ops() ([]Op, error) {
+	var ops []Op
+	for _, v := range d.objects {
+		var (
+			id    = objectID(v)
+			obj   = objectOf(v)
+			token OpToken
+			err   error
+		)
+		for {
+			token, err = obj.NextOp(token)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return nil, fmt.Errorf("unable to read ops of object %v: %w", id, err)
+			}
+
+			op := copyOp(token.Op)
+			op.Obj = id
+			ops = append(ops, op)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].ID.Compare(ops[j].ID) < 0
+	})
+	return ops, nil
+}
+
+// validateChange returns an error if any op of change targets an object or references an
+// op that neither d nor an earlier op of the change holds, or if an attached op, such as a
+// delete, references something other than an element.  As the dependencies of change have
+// been applied, such an op could never be applied.
+func (d *Document) validateChange(change *Change) error {
+	var (
+		created = map[string]ObjectType{} // objects created by the change
+		ops     = map[string]Op{}         // ops of the change by op id
+	)
+	for _, op := range change.Ops {
+		t, ok := created[op.Obj.String()]
+		v := d.objects[op.Obj.String()]
+		switch v.(type) {
+		case *Map:
+			t, ok = ObjectTypeMap, true
+		case *List, *Text:
+			ok = true
+		}
+		if !ok {
+			return fmt.Errorf("unable to apply op (%v,%v) to object %v: %w", op.ID.Counter, op.ID.Actor, op.Obj, ErrObjectNotFound)
+		}
+
+		obj := objectOf(v)
+		switch ref, ok := ops[op.Ref.String()]; {
+		case ok && ref.Obj.Equal(op.Obj):
+			if obj != nil && obj.isAttached(op.Type) && obj.isAttached(ref.Type) {
+				return fmt.Errorf("unable to apply op (%v,%v): ref (%v,%v) is not an element: %w", op.ID.Counter, op.ID.Actor, op.Ref.Counter, op.Ref.Actor, ErrInvalidRef)
+			}
+		case obj != nil:
+			if err := obj.validateRef(op); err != nil {
+				return fmt.Errorf("unable to apply op (%v,%v): %w", op.ID.Counter, op.ID.Actor, err)
+			}
+		case op.Ref.Counter != 0 || len(op.Ref.Actor) != 0:
+			return fmt.Errorf("unable to apply op (%v,%v): unable to find ref (%v,%v): %w", op.ID.Counter, op.ID.Actor, op.Ref.Counter, op.Ref.Actor, ErrRefNotFound)
+		}
+
+		if t == ObjectTypeMap {
+			if _, _, err := decodeMapEntry(op.Value.Bytes); err != nil {
+				return fmt.Errorf("unable to apply op (%v,%v): %w", op.ID.Counter, op.ID.Actor, err)
+			}
+			if t := objectType(op.Type); t != ObjectTypeUnknown {
+				created[op.ID.String()] = t
+			}
+		}
+		ops[op.ID.String()] = op
+	}
+	return nil
+}
+
+// missingDep returns the first dependency of change that has not been applied
+func (d *Document) missingDep(change *Change) (Hash, bool) {
+	for _, dep := range change.Deps {
+		if _, ok := d.changes[dep]; !ok {
+			return dep, true
+		}
+	}
+	return Hash{}, false
+}
+
+// Pending returns the remote ops and changes held because their prerequisites have not
+// been applied
+func (d *Document) Pending() ([]Op, []*Change) {
+	return d.queue.Ops(), d.queue.Changes()
+}
+
+// Missing returns the op ids and change hashes that held ops and changes are waiting on
+func (d *Document) Missing() ([]ID, []Hash) {
+	return d.queue.Missing()
+}
+
+// addChange records change and replaces the heads it depends on with hash
+func (d *Document) addChange(hash Hash, change *Change) {
+	heads := d.heads[:0]
+	for _, head := range d.heads {
+		if !containsHash(change.Deps, head) {
+			heads = append(heads, head)
+		}
+	}
+	heads = append(heads, hash)
+	sort.Slice(heads, func(i, j int) bool {
+		return bytes.Compare(heads[i][:], heads[j][:]) < 0
+	})
+
+	d.heads = heads
+	d.changes[hash] = change
+	d.history = append(d.history, change)
+}
+
+// Heads returns the hashes of the changes no other change depends on in sorted order
+func (d *Document) Heads() []Hash {
+	if len(d.heads) == 0 {
+		return nil
+	}
+	return append([]Hash(nil), d.heads...)
+}
+
+// Changes returns the changes applied to the document in the order they were applied
+func (d *Document) Changes() []*Change {
+	return append([]*Change(nil), d.history...)
+}
+
+func containsHash(hashes []Hash, hash Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
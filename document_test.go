@@ -0,0 +1,610 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+func TestDocument(t *testing.T) {
+	doc := NewDocument([]byte("a"))
+
+	if err := doc.Root().Set("title", encoding.StringValue("notes")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	text, err := doc.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.InsertAt(0, "hello"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	meta, err := doc.NewMap(RootID, "meta")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	tags, err := doc.NewList(meta.id, "tags")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := tags.Insert(0, encoding.StringValue("go")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	t.Run("lookup", func(t *testing.T) {
+		id, typ, ok := doc.Lookup(RootID, "body")
+		if !ok {
+			t.Fatalf("got false; want true")
+		}
+		if want, got := ObjectTypeText, typ; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		got, ok := doc.Text(id)
+		if !ok || got != text {
+			t.Fatalf("got %v; want text", got)
+		}
+
+		if _, _, ok := doc.Lookup(RootID, "title"); ok {
+			t.Fatalf("got true; want false")
+		}
+
+		id, typ, ok = doc.Lookup(meta.id, "tags")
+		if !ok {
+			t.Fatalf("got false; want true")
+		}
+		if want, got := ObjectTypeList, typ; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if _, ok := doc.List(id); !ok {
+			t.Fatalf("got false; want true")
+		}
+	})
+
+	t.Run("shared clock", func(t *testing.T) {
+		// title, body, 5 runes, meta, tags, go
+		if want, got := int64(10), doc.clock.counter; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := (Clock{"a": 10}), doc.Clock(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("remote", func(t *testing.T) {
+		replica := NewDocument([]byte("b"))
+
+		apply := func(obj *Object, id ID) {
+			for _, op := range readAllOps(t, obj) {
+				op.Obj = id
+				if err := replica.Apply(op); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+			}
+		}
+		apply(doc.Root().obj, RootID)
+		apply(text.obj, text.id)
+		apply(meta.obj, meta.id)
+		apply(tags.obj, tags.id)
+
+		id, _, ok := replica.Lookup(RootID, "body")
+		if !ok {
+			t.Fatalf("got false; want true")
+		}
+		got, ok := replica.Text(id)
+		if !ok {
+			t.Fatalf("got false; want true")
+		}
+		if want, got := "hello", got.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+
+		// local edits on the replica order after everything it has seen
+		if err := got.InsertAt(5, "!"); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := int64(11), replica.clock.counter; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		if _, err := doc.NewText(NewID(99, []byte("x")), "key"); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+		if _, _, ok := doc.Lookup(NewID(99, []byte("x")), "key"); ok {
+			t.Fatalf("got true; want false")
+		}
+	})
+}
+
+func TestDocument_Commit(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	b := NewDocument([]byte("b"))
+
+	change, err := a.Commit("empty")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if change != nil {
+		t.Fatalf("got %v; want nil", change)
+	}
+
+	text, err := a.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.InsertAt(0, "abc"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	first, err := a.Commit("create body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := 4, len(first.Ops); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := int64(1), first.Seq; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := int64(1), first.StartOp; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := len(first.Deps); got != 0 {
+		t.Fatalf("got %v; want 0", got)
+	}
+	firstHash, err := first.Hash()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	if err := text.DeleteAt(1, 1); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	second, err := a.Commit("delete")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := int64(2), second.Seq; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := []Hash{firstHash}, second.Deps; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Run("missing dependency", func(t *testing.T) {
+		if err := b.ApplyChange(second); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		_, changes := b.Pending()
+		if want, got := 1, len(changes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		_, hashes := b.Missing()
+		if want, got := []Hash{firstHash}, hashes; !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		for _, change := range a.Changes() {
+			if err := b.ApplyChange(change); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+		// applying a change twice has no effect
+		if err := b.ApplyChange(first); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if _, changes := b.Pending(); len(changes) != 0 {
+			t.Fatalf("got %v; want 0", len(changes))
+		}
+
+		id, _, ok := b.Lookup(RootID, "body")
+		if !ok {
+			t.Fatalf("got false; want true")
+		}
+		got, _ := b.Text(id)
+		if want, got := "ac", got.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := a.Heads(), b.Heads(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		if err := a.Root().Set("x", encoding.Int64Value(1)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := b.Root().Set("y", encoding.Int64Value(2)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		ca, err := a.Commit("")
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		cb, err := b.Commit("")
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := a.ApplyChange(cb); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := b.ApplyChange(ca); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		if want, got := 2, len(a.Heads()); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := a.Heads(), b.Heads(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := a.Root().Keys(), b.Root().Keys(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestDocument_Pending(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	text, err := a.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.InsertAt(0, "abc"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.DeleteAt(1, 1); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	change, err := a.Commit("")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// deliver ops in reverse so each arrives before the op it depends on
+	b := NewDocument([]byte("b"))
+	ops := change.Ops
+	for i := len(ops) - 1; i > 0; i-- {
+		if err := b.Apply(ops[i]); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+
+	pending, _ := b.Pending()
+	if want, got := len(ops)-1, len(pending); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	// every op waits on the text object created by the first op
+	missing, _ := b.Missing()
+	if want, got := 1, len(missing); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := ops[0].ID, missing[0]; !got.Equal(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if err := b.Apply(ops[0]); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if pending, _ := b.Pending(); len(pending) != 0 {
+		t.Fatalf("got %v; want 0", len(pending))
+	}
+
+	got, ok := b.Text(ops[0].ID)
+	if !ok {
+		t.Fatalf("got false; want true")
+	}
+	if want, got := "ac", got.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDocument_ApplyChange(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	text, err := a.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.InsertAt(0, "abc"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	change, err := a.Commit("")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	t.Run("invalid ref", func(t *testing.T) {
+		invalid := *change
+		invalid.Ops = append([]Op(nil), change.Ops...)
+		invalid.Ops[len(invalid.Ops)-1].Ref = NewID(99, []byte("z"))
+
+		b := NewDocument([]byte("b"))
+		if err := b.ApplyChange(&invalid); !errors.Is(err, ErrRefNotFound) {
+			t.Fatalf("got %v; want %v", err, ErrRefNotFound)
+		}
+
+		// none of the ops of the change were applied
+		if _, _, ok := b.Lookup(RootID, "body"); ok {
+			t.Fatalf("got true; want false")
+		}
+		if got := len(b.Heads()); got != 0 {
+			t.Fatalf("got %v; want 0", got)
+		}
+		if pending, changes := b.Pending(); len(pending)+len(changes) != 0 {
+			t.Fatalf("got %v, %v; want none", pending, changes)
+		}
+
+		if err := b.ApplyChange(change); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		got, _ := b.Text(text.id)
+		if want, got := "abc", got.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("delete without target", func(t *testing.T) {
+		b := NewDocument([]byte("b"))
+		if err := b.ApplyChange(change); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		// deletes of the start of the text and of another delete in the same change
+		var (
+			c     = []byte("c")
+			del   = Op{Obj: text.id, ID: NewID(10, c), Type: TextDelete}
+			first = Op{Obj: text.id, ID: NewID(9, c), Ref: change.Ops[1].ID, Type: TextDelete}
+			next  = Op{Obj: text.id, ID: NewID(10, c), Ref: first.ID, Type: TextDelete}
+		)
+		for _, ops := range [][]Op{{del}, {first, next}} {
+			invalid := &Change{Actor: c, Seq: 1, StartOp: ops[0].ID.Counter, Deps: b.Heads(), Ops: ops}
+			if err := b.ApplyChange(invalid); !errors.Is(err, ErrInvalidRef) {
+				t.Fatalf("got %v; want %v", err, ErrInvalidRef)
+			}
+		}
+		if err := b.Apply(del); !errors.Is(err, ErrInvalidRef) {
+			t.Fatalf("got %v; want %v", err, ErrInvalidRef)
+		}
+
+		got, _ := b.Text(text.id)
+		if want, got := int64(3), got.RowCount(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("invalid object", func(t *testing.T) {
+		invalid := *change
+		invalid.Ops = append([]Op(nil), change.Ops...)
+		invalid.Ops[1].Obj = NewID(99, []byte("z"))
+
+		b := NewDocument([]byte("b"))
+		if err := b.ApplyChange(&invalid); !errors.Is(err, ErrObjectNotFound) {
+			t.Fatalf("got %v; want %v", err, ErrObjectNotFound)
+		}
+		if _, _, ok := b.Lookup(RootID, "body"); ok {
+			t.Fatalf("got true; want false")
+		}
+	})
+}
+
+func TestDocument_Merge(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	b := NewDocument([]byte("b"))
+
+	text, err := a.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.InsertAt(0, "abc"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if _, err := a.Commit(""); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Merge(a); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	if err := text.InsertAt(3, "d"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if _, err := a.Commit(""); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	other, _ := b.Text(text.id)
+	if err := other.InsertAt(0, "z"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Root().Set("k", encoding.Int64Value(1)); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if _, err := b.Commit(""); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := a.Merge(b); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := b.Merge(a); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+
+	if want, got := "zabcd", text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := text.String(), other.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := a.Heads(), b.Heads(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := len(a.Changes()), len(b.Changes()); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, ok := a.Root().Get("k"); !ok {
+		t.Fatalf("got false; want true")
+	}
+}
+
+func TestDocument_MergeUncommitted(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	b := NewDocument([]byte("b"))
+
+	text, err := a.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.InsertAt(0, "abc"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if _, err := a.Commit(""); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Merge(a); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// neither replica commits its edits
+	if err := text.InsertAt(3, "d"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	other, _ := b.Text(text.id)
+	if err := other.InsertAt(0, "z"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Root().Set("k", encoding.Int64Value(1)); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// an op applied to b outside of any change
+	c := NewDocument([]byte("c"))
+	if err := c.Merge(b); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := c.Root().Set("j", encoding.Int64Value(2)); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	change, err := c.Commit("")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Apply(change.Ops[0]); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Merge(a); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	for _, doc := range []*Document{a, b} {
+		got, _ := doc.Text(text.id)
+		if want, got := "zabcd", got.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := []string{"body", "j", "k"}, doc.Root().Keys(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	// once committed, the changes carry ops both replicas already hold
+	if _, err := a.Commit(""); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if _, err := b.Commit(""); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Merge(a); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := a.Heads(), b.Heads(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := text.String(), other.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDocument_ValueTypes(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	list, err := a.NewList(RootID, "list")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := list.Insert(0, encoding.StringValue("x")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := a.Root().Set("k", encoding.StringValue("y")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	change, err := a.Commit("")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// ops outside of any change are copied by Merge
+	if err := list.Set(0, encoding.StringValue("z")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	data, err := change.MarshalBinary()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	var decoded Change
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	b := NewDocument([]byte("b"))
+	if err := b.ApplyChange(&decoded); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Merge(a); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	other, _ := b.List(list.id)
+	v, err := other.Get(0)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := "z", string(v.Bytes); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := encoding.ValueTypeUTF8, v.Type; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	v, _ = b.Root().Get("k")
+	if want, got := encoding.ValueTypeUTF8, v.Type; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
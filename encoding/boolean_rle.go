@@ -0,0 +1,400 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// BooleanRLE encodes booleans as a sequence of var int run lengths whose values alternate
+// starting with false.  A sequence beginning with true starts with a run of length 0.
+type BooleanRLE struct {
+	buffer []byte
+	tail   booleanRun // last run, valid when tailOk
+	tailOk bool       // tailOk is cleared by any write other than Append
+}
+
+type BooleanRLEToken struct {
+	Pos    int
+	Repeat int
+	Index  int
+	Value  bool
+	run    int // number of runs read
+}
+
+// booleanRun describes a run within the buffer
+type booleanRun struct {
+	Pos    int    // byte position of the run
+	Length int    // encoded length of the run
+	Row    int64  // row of the first value in the run
+	Count  uint64 // number of values in the run
+	Index  int    // index of the run; odd runs hold true
+}
+
+func (r booleanRun) Value() bool {
+	return r.Index%2 == 1
+}
+
+func NewBooleanRLE(buffer []byte) *BooleanRLE {
+	return &BooleanRLE{buffer: buffer}
+}
+
+// runs calls fn with each run until fn returns false
+func (b *BooleanRLE) runs(fn func(run booleanRun) bool) error {
+	var row int64
+	for pos, index := 0, 0; pos < len(b.buffer); index++ {
+		count, n := binary.Uvarint(b.buffer[pos:])
+		if n <= 0 {
+			return io.ErrUnexpectedEOF
+		}
+
+		run := booleanRun{Pos: pos, Length: n, Row: row, Count: count, Index: index}
+		if !fn(run) {
+			return nil
+		}
+
+		row += int64(count)
+		pos += n
+	}
+	return nil
+}
+
+// find returns the run holding index along with the run preceding it.  When index equals
+// the row count, the last run is returned with ok false.
+func (b *BooleanRLE) find(index int64) (prev, run booleanRun, ok bool, err error) {
+	var last booleanRun
+	err = b.runs(func(r booleanRun) bool {
+		if index >= r.Row && index < r.Row+int64(r.Count) {
+			prev, run, ok = last, r, true
+			return false
+		}
+		last = r
+		return true
+	})
+	if !ok {
+		run = last
+	}
+	return prev, run, ok, err
+}
+
+// replace substitutes the length bytes at pos with the encoded counts
+func (b *BooleanRLE) replace(pos, length int, counts ...uint64) {
+	var data []byte
+	for _, count := range counts {
+		var buf [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(buf[:], count)
+		data = append(data, buf[:n]...)
+	}
+
+	switch delta := len(data) - length; {
+	case delta > 0:
+		b.buffer = shift(b.buffer, pos, delta)
+	case delta < 0:
+		b.buffer = unshift(b.buffer, pos, -delta)
+	}
+	copy(b.buffer[pos:], data)
+
+	// a lone empty run of false encodes no values
+	if len(b.buffer) == 1 && b.buffer[0] == 0 {
+		b.buffer = b.buffer[:0]
+	}
+}
+
+func (b *BooleanRLE) Get(index int64) (bool, error) {
+	_, run, ok, err := b.find(index)
+	if err != nil {
+		return false, fmt.Errorf("unable to get value at index, %v: %w", index, err)
+	}
+	if !ok {
+		return false, io.ErrUnexpectedEOF
+	}
+	return run.Value(), nil
+}
+
+func (b *BooleanRLE) InsertAt(index int64, v bool) error {
+	b.tailOk = false
+	if index < 0 {
+		return fmt.Errorf("unable to insert value, %v, at index, %v: %w", v, index, io.ErrUnexpectedEOF)
+	}
+
+	var (
+		done bool
+		last booleanRun
+		rows int64
+	)
+	err := b.runs(func(r booleanRun) bool {
+		last, rows = r, r.Row+int64(r.Count)
+		switch {
+		case r.Value() == v && index >= r.Row && index <= r.Row+int64(r.Count):
+			b.replace(r.Pos, r.Length, r.Count+1)
+			done = true
+
+		case index == r.Row && r.Count > 0: // only reachable by inserting true before the first run
+			b.replace(r.Pos, r.Length, 0, 1, r.Count)
+			done = true
+
+		case index > r.Row && index < r.Row+int64(r.Count):
+			before := uint64(index - r.Row)
+			b.replace(r.Pos, r.Length, before, 1, r.Count-before)
+			done = true
+		}
+		return !done
+	})
+	if err != nil {
+		return fmt.Errorf("unable to insert value, %v, at index, %v: %w", v, index, err)
+	}
+	if done {
+		return nil
+	}
+	if index != rows {
+		return fmt.Errorf("unable to insert value, %v, at index, %v: %w", v, index, io.ErrUnexpectedEOF)
+	}
+
+	// new run
+	switch {
+	case len(b.buffer) == 0 && v:
+		b.replace(0, 0, 0, 1)
+	case len(b.buffer) == 0:
+		b.replace(0, 0, 1)
+	default:
+		b.replace(last.Pos+last.Length, 0, 1)
+	}
+	return nil
+}
+
+// Append adds v after the last value.  The last run, and with it the row count, is cached
+// so that successive appends need not scan the buffer.
+func (b *BooleanRLE) Append(v bool) error {
+	if !b.tailOk {
+		var tail booleanRun
+		if err := b.runs(func(r booleanRun) bool { tail = r; return true }); err != nil {
+			return fmt.Errorf("unable to append value, %v: %w", v, err)
+		}
+		b.tail, b.tailOk = tail, true
+	}
+
+	tail := b.tail
+	switch {
+	case len(b.buffer) == 0 && v:
+		b.replace(0, 0, 0, 1)
+		b.tail = booleanRun{Pos: 1, Length: 1, Count: 1, Index: 1}
+	case len(b.buffer) == 0:
+		b.replace(0, 0, 1)
+		b.tail = booleanRun{Pos: 0, Length: 1, Count: 1}
+	case tail.Value() == v:
+		var buf [binary.MaxVarintLen64]byte
+		b.replace(tail.Pos, tail.Length, tail.Count+1)
+		b.tail.Count++
+		b.tail.Length = binary.PutUvarint(buf[:], b.tail.Count)
+	default:
+		pos := tail.Pos + tail.Length
+		b.replace(pos, 0, 1)
+		b.tail = booleanRun{Pos: pos, Length: 1, Row: tail.Row + int64(tail.Count), Count: 1, Index: tail.Index + 1}
+	}
+	return nil
+}
+
+func (b *BooleanRLE) DeleteAt(index int64) error {
+	b.tailOk = false
+	if index < 0 {
+		return fmt.Errorf("unable to delete @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+
+	prev, run, ok, err := b.find(index)
+	if err != nil {
+		return fmt.Errorf("unable to delete @%v: %w", index, err)
+	}
+	if !ok {
+		return fmt.Errorf("unable to delete @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+
+	if run.Count > 1 || run.Index == 0 {
+		b.replace(run.Pos, run.Length, run.Count-1)
+		return nil
+	}
+
+	// the run is emptied; join the runs either side as they hold the same value
+	end := run.Pos + run.Length
+	if end == len(b.buffer) {
+		b.buffer = b.buffer[:run.Pos]
+		if len(b.buffer) == 1 && b.buffer[0] == 0 {
+			b.buffer = b.buffer[:0]
+		}
+		return nil
+	}
+
+	next, n := binary.Uvarint(b.buffer[end:])
+	if n <= 0 {
+		return fmt.Errorf("unable to delete @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+	b.replace(prev.Pos, prev.Length+run.Length+n, prev.Count+next)
+	return nil
+}
+
+func (b *BooleanRLE) Next(token BooleanRLEToken) (BooleanRLEToken, error) {
+	if token.Repeat > 0 {
+		return BooleanRLEToken{
+			Pos:    token.Pos,
+			Repeat: token.Repeat - 1,
+			Index:  token.Index + 1,
+			Value:  token.Value,
+			run:    token.run,
+		}, nil
+	}
+
+	index := token.Index + 1
+	if token.run == 0 {
+		index = 0
+	}
+
+	for pos, run := token.Pos, token.run; pos < len(b.buffer); run++ {
+		count, n := binary.Uvarint(b.buffer[pos:])
+		if n <= 0 {
+			return BooleanRLEToken{}, io.ErrUnexpectedEOF
+		}
+		pos += n
+
+		if count == 0 {
+			continue
+		}
+		return BooleanRLEToken{
+			Pos:    pos,
+			Repeat: int(count) - 1,
+			Index:  index,
+			Value:  run%2 == 1,
+			run:    run + 1,
+		}, nil
+	}
+	return BooleanRLEToken{}, io.EOF
+}
+
+// Bools returns all values
+func (b *BooleanRLE) Bools() ([]bool, error) {
+	var values []bool
+	err := b.runs(func(r booleanRun) bool {
+		for i := uint64(0); i < r.Count; i++ {
+			values = append(values, r.Value())
+		}
+		return true
+	})
+	return values, err
+}
+
+// Raw returns the underlying encoded bytes
+func (b *BooleanRLE) Raw() []byte {
+	return b.buffer
+}
+
+// RowCount returns the number of values encoded by summing the run lengths
+func (b *BooleanRLE) RowCount() int {
+	if b.tailOk {
+		return int(b.tail.Row + int64(b.tail.Count))
+	}
+
+	var n int64
+	_ = b.runs(func(r booleanRun) bool {
+		n += int64(r.Count)
+		return true
+	})
+	return int(n)
+}
+
+func (b *BooleanRLE) Size() int {
+	return len(b.buffer)
+}
+
+func (b *BooleanRLE) SplitAt(index int64) (left, right *BooleanRLE, err error) {
+	if index < 0 {
+		return nil, nil, fmt.Errorf("unable to split on negative index")
+	}
+	if index == 0 {
+		return NewBooleanRLE(nil), NewBooleanRLE(append([]byte(nil), b.buffer...)), nil
+	}
+
+	_, run, ok, err := b.find(index)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to split at index, %v: %w", index, err)
+	}
+	if !ok {
+		if int64(b.RowCount()) == index {
+			return b, NewBooleanRLE(nil), nil
+		}
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+
+	var (
+		before = uint64(index - run.Row)
+		rest   = b.buffer[run.Pos+run.Length:]
+	)
+
+	left = NewBooleanRLE(append(make([]byte, 0, run.Pos+binary.MaxVarintLen64), b.buffer[:run.Pos]...))
+	if before > 0 {
+		left.replace(run.Pos, 0, before)
+	}
+
+	// right must begin with a run of false
+	right = NewBooleanRLE(make([]byte, 0, len(rest)+2*binary.MaxVarintLen64))
+	if run.Value() {
+		right.replace(0, 0, 0, run.Count-before)
+	} else {
+		right.replace(0, 0, run.Count-before)
+	}
+	right.buffer = append(right.buffer, rest...)
+
+	return left, right, nil
+}
+
+// Concat returns a BooleanRLE holding the values of b followed by the values of that.  The
+// last run of b is joined with the first run of that when they hold the same value.
+func (b *BooleanRLE) Concat(that *BooleanRLE) (*BooleanRLE, error) {
+	v := NewBooleanRLE(append(make([]byte, 0, len(b.buffer)+len(that.buffer)), b.buffer...))
+	if len(that.buffer) == 0 {
+		return v, nil
+	}
+	if len(b.buffer) == 0 {
+		v.buffer = append(v.buffer, that.buffer...)
+		return v, nil
+	}
+
+	var last booleanRun
+	if err := b.runs(func(r booleanRun) bool { last = r; return true }); err != nil {
+		return nil, fmt.Errorf("unable to concat booleans: %w", err)
+	}
+
+	// that begins with a run of false; when empty, that begins with its run of true
+	rest, value := that.buffer, false
+	first, n := binary.Uvarint(rest)
+	if n <= 0 {
+		return nil, fmt.Errorf("unable to concat booleans: %w", io.ErrUnexpectedEOF)
+	}
+	if first == 0 {
+		if rest, value = rest[n:], true; len(rest) == 0 {
+			return v, nil
+		}
+		if first, n = binary.Uvarint(rest); n <= 0 {
+			return nil, fmt.Errorf("unable to concat booleans: %w", io.ErrUnexpectedEOF)
+		}
+	}
+
+	if last.Value() != value {
+		v.buffer = append(v.buffer, rest...)
+		return v, nil
+	}
+	v.replace(last.Pos, last.Length, last.Count+first)
+	v.buffer = append(v.buffer, rest[n:]...)
+	return v, nil
+}
@@ -0,0 +1,263 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBooleanRLE_InsertAt(t *testing.T) {
+	testCases := map[string]struct {
+		Values []bool
+		Want   []byte
+	}{
+		"empty":         {Values: nil, Want: nil},
+		"false":         {Values: []bool{false, false}, Want: []byte{2}},
+		"true":          {Values: []bool{true, true, true}, Want: []byte{0, 3}},
+		"alternating":   {Values: []bool{false, true, true, false}, Want: []byte{1, 2, 1}},
+		"leading true":  {Values: []bool{true, false, false, true}, Want: []byte{0, 1, 2, 1}},
+		"two byte runs": {Values: make([]bool, 200), Want: []byte{200, 1}},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			b := NewBooleanRLE(nil)
+			for i, v := range tc.Values {
+				if err := b.InsertAt(int64(i), v); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+			}
+			if got := b.Raw(); !reflect.DeepEqual(got, tc.Want) && len(got)+len(tc.Want) > 0 {
+				t.Fatalf("got %v, want %v", got, tc.Want)
+			}
+			if want, got := len(tc.Values), b.RowCount(); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("out of range", func(t *testing.T) {
+		b := NewBooleanRLE(nil)
+		if err := b.InsertAt(1, true); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+}
+
+func TestBooleanRLE_Append(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(1))
+		b    = NewBooleanRLE(nil)
+		want []bool
+	)
+	for i := 0; i < 1000; i++ {
+		v := rng.Intn(3) == 0
+		switch op := rng.Intn(10); {
+		case op == 0 && len(want) > 0:
+			index := rng.Intn(len(want))
+			if err := b.DeleteAt(int64(index)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:index], want[index+1:]...)
+		case op == 1:
+			index := rng.Intn(len(want) + 1)
+			if err := b.InsertAt(int64(index), v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:index], append([]bool{v}, want[index:]...)...)
+		default:
+			if err := b.Append(v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want, v)
+		}
+
+		if want, got := len(want), b.RowCount(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	got, err := b.Bools()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// appends match the encoding produced by InsertAt
+	appended := NewBooleanRLE(nil)
+	for _, v := range append([]bool{true}, make([]bool, 200)...) {
+		if err := appended.Append(v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	if want, got := []byte{0, 1, 200, 1}, appended.Raw(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestBooleanRLE_DeleteAt(t *testing.T) {
+	var (
+		random = rand.New(rand.NewSource(1))
+		b      = NewBooleanRLE(nil)
+		want   []bool
+	)
+	for i := 0; i < 2000; i++ {
+		if len(want) == 0 || random.Intn(3) > 0 {
+			index, v := random.Intn(len(want)+1), random.Intn(2) == 0
+			if err := b.InsertAt(int64(index), v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:index], append([]bool{v}, want[index:]...)...)
+		} else {
+			index := random.Intn(len(want))
+			if err := b.DeleteAt(int64(index)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:index], want[index+1:]...)
+		}
+
+		got, err := b.Bools()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if !reflect.DeepEqual(got, want) && len(got)+len(want) > 0 {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+
+		// runs are never empty other than a leading run of false
+		for pos, run := 0, 0; pos < len(b.buffer); run++ {
+			if b.buffer[pos] == 0 && (run > 0 || pos+1 == len(b.buffer)) {
+				t.Fatalf("got empty run, %v, in %v", run, b.buffer)
+			}
+			for b.buffer[pos]&0x80 != 0 {
+				pos++
+			}
+			pos++
+		}
+	}
+
+	if err := b.DeleteAt(int64(len(want))); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestBooleanRLE_Next(t *testing.T) {
+	values := []bool{true, true, false, true, false, false, false}
+	b := NewBooleanRLE(nil)
+	for _, v := range values {
+		if err := b.Append(v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+
+	var token BooleanRLEToken
+	var err error
+	for i, want := range values {
+		token, err = b.Next(token)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if token.Index != i || token.Value != want {
+			t.Fatalf("got (%v,%v); want (%v,%v)", token.Index, token.Value, i, want)
+		}
+
+		got, err := b.Get(int64(i))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+	if _, err := b.Next(token); !errors.Is(err, io.EOF) {
+		t.Fatalf("got %v; want %v", err, io.EOF)
+	}
+}
+
+func TestBooleanRLE_SplitAt(t *testing.T) {
+	values := []bool{true, true, false, true, false, false, false}
+	for index := 0; index <= len(values); index++ {
+		b := NewBooleanRLE(nil)
+		for _, v := range values {
+			if err := b.Append(v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+
+		left, right, err := b.SplitAt(int64(index))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		l, _ := left.Bools()
+		r, _ := right.Bools()
+		if want := values[:index]; !reflect.DeepEqual(l, want) && len(l)+len(want) > 0 {
+			t.Fatalf("got %v, want %v", l, want)
+		}
+		if want := values[index:]; !reflect.DeepEqual(r, want) && len(r)+len(want) > 0 {
+			t.Fatalf("got %v, want %v", r, want)
+		}
+	}
+
+	if _, _, err := NewBooleanRLE(nil).SplitAt(1); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestBooleanRLE_Concat(t *testing.T) {
+	values := []bool{true, true, false, true, false, false, false}
+	for index := 0; index <= len(values); index++ {
+		var (
+			left  = NewBooleanRLE(nil)
+			right = NewBooleanRLE(nil)
+			want  = NewBooleanRLE(nil)
+		)
+		for i, v := range values {
+			r := left
+			if i >= index {
+				r = right
+			}
+			if err := r.Append(v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if err := want.Append(v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+
+		got, err := left.Concat(right)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if !reflect.DeepEqual(got.Raw(), want.Raw()) {
+			t.Fatalf("got %v, want %v", got.Raw(), want.Raw())
+		}
+
+		// appends continue from the concatenated runs
+		if err := got.Append(true); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		bools, _ := got.Bools()
+		if want := append(append([]bool(nil), values...), true); !reflect.DeepEqual(bools, want) {
+			t.Fatalf("got %v, want %v", bools, want)
+		}
+	}
+}
@@ -22,11 +22,15 @@ import (
 type Delta struct {
 	rle     *RLE
 	numRows int64
+	last    int64 // last value, valid when lastOk
+	lastOk  bool
 }
 
 type DeltaToken struct {
 	rle   RLEToken
+	sum   int64 // sum holds the last value that was not null
 	Value int64
+	Null  bool // Null is set for absent values; Value is then 0
 }
 
 func NewDelta(buffer []byte) *Delta {
@@ -38,84 +42,255 @@ func NewDelta(buffer []byte) *Delta {
 	}
 }
 
-func (d *Delta) Get(index int64) (int64, error) {
-	var i int64
-	var token DeltaToken
+// Validate returns an error unless the buffer decodes to exactly rows values
+func (d *Delta) Validate(rows int64) error {
+	return d.rle.Validate(rows)
+}
+
+// EnableSkipIndex maintains the byte position and value of every interval runs so that
+// reads and writes seek part way into the buffer rather than summing from the start.  An
+// interval <= 0 disables the index.
+func (d *Delta) EnableSkipIndex(interval int) {
+	d.rle.EnableSkipIndex(interval)
+}
+
+// seek returns the row of a run beginning at or before index along with a token that
+// yields that row from Next
+func (d *Delta) seek(index int64) (int64, DeltaToken) {
+	entry := d.rle.seek(index)
+	if entry.Pos == 0 {
+		return 0, DeltaToken{}
+	}
+	return entry.Row, DeltaToken{
+		rle: RLEToken{Pos: entry.Pos, Index: int(entry.Row) - 1},
+		sum: entry.Sum,
+	}
+}
+
+// before returns a token that yields index from Next.  The sum of the token holds the last
+// value preceding index that is not null.
+func (d *Delta) before(index int64) (DeltaToken, error) {
+	i, token := d.seek(index - 1)
 	var err error
-	for {
+	for ; i < index; i++ {
 		token, err = d.Next(token)
 		if err != nil {
-			return 0, nil
+			if err == io.EOF {
+				return DeltaToken{}, io.ErrUnexpectedEOF
+			}
+			return DeltaToken{}, err
 		}
+	}
+	return token, nil
+}
 
-		if index == i {
-			return token.Value, nil
+// Get returns the value at index; 0 if the value is null
+func (d *Delta) Get(index int64) (int64, error) {
+	token, err := d.before(index)
+	if err != nil {
+		return 0, err
+	}
+	token, err = d.Next(token)
+	if err != nil {
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
 		}
-
-		i++
+		return 0, err
 	}
+	return token.Value, nil
+}
+
+// IsNull returns true if the value at index is null
+func (d *Delta) IsNull(index int64) (bool, error) {
+	return d.rle.IsNull(index)
 }
 
-func (d *Delta) InsertAt(index, value int64) error {
+// InsertAt inserts values, in order, at the given index
+func (d *Delta) InsertAt(index int64, values ...int64) error {
 	switch {
-	case index < 0 || index > int64(d.numRows):
+	case index < 0 || index > d.numRows:
 		return io.ErrUnexpectedEOF
 
-	case d.numRows == 0: // empty
-		d.numRows++
-		return d.rle.InsertAt(0, value)
+	case len(values) == 0:
+		return nil
 
-	case index == 0: // head
-		d.numRows++
-		v, err := d.rle.Get(index)
-		if err != nil {
-			return err
-		}
-		if err := d.rle.DeleteAt(index); err != nil {
-			return err
-		}
-		if err := d.rle.InsertAt(index, value); err != nil {
-			return err
+	case index == d.numRows: // tail, including empty
+		for _, value := range values {
+			if err := d.Append(value); err != nil {
+				return err
+			}
 		}
-		return d.rle.InsertAt(index+1, v-value)
+		return nil
 	}
 
-	var i int64
-	var lastValue int64
-	var token DeltaToken
+	token, err := d.before(index)
+	if err != nil {
+		return fmt.Errorf("unable to insert delta, %v@%v: %w", values[0], index, err)
+	}
+
+	deltas := make([]int64, len(values))
+	last := token.sum
+	for i, value := range values {
+		deltas[i] = value - last
+		last = value
+	}
+	if err := d.rle.InsertAt(index, deltas...); err != nil {
+		return fmt.Errorf("unable to insert delta, %v@%v: %w", values[0], index, err)
+	}
+	n := int64(len(values))
+	d.numRows += n
+
+	// the following value is now relative to the last value inserted
+	ok, err := d.rebase(index+n, token.sum-last)
+	if err != nil {
+		return fmt.Errorf("unable to insert delta, %v@%v: %w", values[0], index, err)
+	}
+	if !ok {
+		d.last = last // only nulls follow
+	}
+	return nil
+}
+
+// InsertNullAt inserts a null value at index.  Nulls do not affect the deltas of the values
+// around them.
+func (d *Delta) InsertNullAt(index int64) error {
+	if index < 0 || index > d.numRows {
+		return fmt.Errorf("unable to insert null delta @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+	if err := d.rle.InsertNullAt(index); err != nil {
+		return fmt.Errorf("unable to insert null delta @%v: %w", index, err)
+	}
+	d.numRows++
+	return nil
+}
+
+// rebase adds delta to the delta of the first value at or after index that is not null.
+// rebase returns false if only nulls follow index.
+func (d *Delta) rebase(index, delta int64) (bool, error) {
+	j, ok, err := d.rle.nextValue(index)
+	if err != nil || !ok {
+		return false, err
+	}
+	if delta == 0 {
+		return true, nil
+	}
+
+	v, err := d.rle.Get(j)
+	if err != nil {
+		return false, err
+	}
+	if err := d.rle.DeleteAt(j); err != nil {
+		return false, err
+	}
+	if err := d.rle.InsertAt(j, v+delta); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// DeleteAt removes the value at index.  The delta of the following value is adjusted so
+// it remains relative to the value now preceding it.
+func (d *Delta) DeleteAt(index int64) error {
+	if index < 0 || index >= d.numRows {
+		return fmt.Errorf("unable to delete delta @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+
+	delta, null, err := d.rle.get(index)
+	if err != nil {
+		return fmt.Errorf("unable to delete delta @%v: %w", index, err)
+	}
+	if err := d.rle.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete delta @%v: %w", index, err)
+	}
+	d.numRows--
+	if null {
+		return nil
+	}
+
+	ok, err := d.rebase(index, delta)
+	if err != nil {
+		return fmt.Errorf("unable to delete delta @%v: %w", index, err)
+	}
+	if !ok {
+		// last value removed; the value preceding it is now last
+		d.last -= delta
+	}
+	return nil
+}
+
+// Append adds value after the last value.  The last value is cached so successive appends
+// need not scan the buffer.  Inserts before the last value leave it unchanged.
+func (d *Delta) Append(value int64) error {
+	last, err := d.lastValue()
+	if err != nil {
+		return fmt.Errorf("unable to append delta, %v: %w", value, err)
+	}
+
+	if err := d.rle.Append(value - last); err != nil {
+		return fmt.Errorf("unable to append delta, %v: %w", value, err)
+	}
+	d.numRows++
+	d.last = value
+	return nil
+}
+
+// AppendNull adds a null value after the last value
+func (d *Delta) AppendNull() error {
+	if err := d.rle.AppendNull(); err != nil {
+		return fmt.Errorf("unable to append null delta: %w", err)
+	}
+	d.numRows++
+	return nil
+}
+
+// lastValue returns the last value that is not null or 0 if there is none
+func (d *Delta) lastValue() (int64, error) {
+	if d.lastOk {
+		return d.last, nil
+	}
+
+	_, token := d.seek(d.numRows - 1)
+	last := token.sum
 	var err error
-	for ; ; i++ {
+	for {
 		token, err = d.Next(token)
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return err
-		}
-
-		if i == index {
-			if err := d.rle.DeleteAt(i); err != nil {
-				return fmt.Errorf("unable to insert delta, %v@%v: %w", value, index, err)
-			}
-			if err := d.rle.InsertAt(i, value-lastValue); err != nil {
-				return fmt.Errorf("unable to insert delta, %v@%v: %w", value, index, err)
-			}
-			if err := d.rle.InsertAt(i+1, token.Value-value); err != nil {
-				return fmt.Errorf("unable to insert delta, %v@%v: %w", value, index, err)
-			}
-			d.numRows++
-			return nil
+			return 0, err
 		}
+		last = token.sum
+	}
+	d.last = last
+	d.lastOk = true
+	return last, nil
+}
 
-		lastValue = token.Value
+// Concat returns a Delta holding the values of d followed by the values of that
+func (d *Delta) Concat(that *Delta) (*Delta, error) {
+	last, err := d.lastValue()
+	if err != nil {
+		return nil, fmt.Errorf("unable to concat delta: %w", err)
 	}
 
-	if i == index {
-		d.numRows++
-		return d.rle.InsertAt(i, value-lastValue)
+	// the first value of that is relative to zero; rebase it on the last value of d
+	right := &Delta{
+		rle:     that.rle.derive(append([]byte(nil), that.rle.buffer...)),
+		numRows: that.numRows,
+	}
+	if _, err := right.rebase(0, -last); err != nil {
+		return nil, fmt.Errorf("unable to concat delta: %w", err)
 	}
 
-	return io.ErrUnexpectedEOF
+	rle, err := d.rle.Concat(right.rle)
+	if err != nil {
+		return nil, fmt.Errorf("unable to concat delta: %w", err)
+	}
+	return &Delta{
+		rle:     rle,
+		numRows: d.numRows + that.numRows,
+	}, nil
 }
 
 func (d *Delta) Next(token DeltaToken) (DeltaToken, error) {
@@ -124,9 +299,19 @@ func (d *Delta) Next(token DeltaToken) (DeltaToken, error) {
 		return DeltaToken{}, err
 	}
 
+	if rleToken.Null {
+		return DeltaToken{
+			rle:  rleToken,
+			sum:  token.sum,
+			Null: true,
+		}, nil
+	}
+
+	sum := token.sum + rleToken.Value
 	return DeltaToken{
 		rle:   rleToken,
-		Value: token.Value + rleToken.Value,
+		sum:   sum,
+		Value: sum,
 	}, nil
 }
 
@@ -139,7 +324,7 @@ func (d *Delta) Size() int {
 }
 
 func (d *Delta) SplitAt(index int64) (left, right *Delta, err error) {
-	v, err := d.Get(index)
+	token, err := d.before(index)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -157,11 +342,10 @@ func (d *Delta) SplitAt(index int64) (left, right *Delta, err error) {
 		rle:     r,
 		numRows: d.numRows - index,
 	}
-	if right.numRows > 0 {
-		if err := right.rle.DeleteAt(0); err != nil {
-			return nil, nil, err
-		}
-		right.rle.writeAtWithShift(0, 1, v)
+
+	// the first value of right is relative to the values of left; make it relative to zero
+	if _, err := right.rebase(0, token.sum); err != nil {
+		return nil, nil, err
 	}
 
 	return
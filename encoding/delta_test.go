@@ -15,7 +15,9 @@
 package encoding
 
 import (
+	"errors"
 	"io"
+	"math/rand"
 	"reflect"
 	"testing"
 )
@@ -142,6 +144,170 @@ func TestDelta_InsertAt(t *testing.T) {
 			t.Fatalf("got %v; want %v", len(got), want)
 		}
 	})
+
+	t.Run("multiple values", func(t *testing.T) {
+		var (
+			rng  = rand.New(rand.NewSource(1))
+			d    = NewDelta(nil)
+			want []int64
+		)
+		d.EnableSkipIndex(4)
+		for i := 0; i < 200; i++ {
+			index := rng.Intn(len(want) + 1)
+			values := make([]int64, rng.Intn(4)+1)
+			for j := range values {
+				values[j] = rng.Int63n(100)
+			}
+			if err := d.InsertAt(int64(index), values...); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:index], append(values, want[index:]...)...)
+
+			if got := readAllDeltaRLE(t, d); !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+		if err := d.Append(7); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got, err := d.Get(int64(len(want))); err != nil || got != 7 {
+			t.Fatalf("got %v, %v; want 7, nil", got, err)
+		}
+	})
+
+}
+
+func TestDelta_Append(t *testing.T) {
+	d := NewDelta(nil)
+	for _, v := range []int64{1, 2, 3, 10} {
+		if err := d.Append(v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	// inserting before the tail leaves the cached last value intact
+	if err := d.InsertAt(0, 0); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.InsertAt(5, 11); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.Append(12); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	if got, want := readAllDeltaRLE(t, d), []int64{0, 1, 2, 3, 10, 11, 12}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Run("after split", func(t *testing.T) {
+		left, _, err := d.SplitAt(3)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := left.Append(5); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got, want := readAllDeltaRLE(t, left), []int64{0, 1, 2, 5}; !reflect.DeepEqual(want, got) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestDelta_SkipIndex(t *testing.T) {
+	var (
+		random = rand.New(rand.NewSource(1))
+		got    = NewDelta(nil)
+		want   = NewDelta(nil)
+	)
+	got.EnableSkipIndex(4)
+
+	for i := 0; i < 1000; i++ {
+		index, v := random.Int63n(want.numRows+1), random.Int63n(100)
+		if random.Intn(4) == 0 {
+			index = want.numRows
+		}
+		for _, d := range []*Delta{got, want} {
+			if err := d.InsertAt(index, v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+
+		index = random.Int63n(want.numRows)
+		g, err := got.Get(index)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		w, err := want.Get(index)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if g != w {
+			t.Fatalf("got %v, want %v", g, w)
+		}
+	}
+
+	values := readAllDeltaRLE(t, want)
+	if got := readAllDeltaRLE(t, got); !reflect.DeepEqual(got, values) {
+		t.Fatalf("got %v, want %v", got, values)
+	}
+
+	left, right, err := got.SplitAt(500)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got := append(readAllDeltaRLE(t, left), readAllDeltaRLE(t, right)...); !reflect.DeepEqual(got, values) {
+		t.Fatalf("got %v, want %v", got, values)
+	}
+}
+
+func TestDelta_DeleteAt(t *testing.T) {
+	testCases := map[string]int{
+		"plain":      0,
+		"skip index": 2,
+	}
+	for label, interval := range testCases {
+		t.Run(label, func(t *testing.T) {
+			var (
+				random = rand.New(rand.NewSource(1))
+				d      = NewDelta(nil)
+				want   []int64
+			)
+			d.EnableSkipIndex(interval)
+			for i := 0; i < 100; i++ {
+				v := random.Int63n(20)
+				if err := d.Append(v); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				want = append(want, v)
+			}
+
+			for len(want) > 0 {
+				index := random.Intn(len(want))
+				if err := d.DeleteAt(int64(index)); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				want = append(want[:index], want[index+1:]...)
+
+				if got := readAllDeltaRLE(t, d); len(want) > 0 && !reflect.DeepEqual(got, want) {
+					t.Fatalf("got %v, want %v", got, want)
+				}
+				if want, got := int64(len(want)), d.numRows; got != want {
+					t.Fatalf("got %v, want %v", got, want)
+				}
+			}
+
+			// the cached last value remains valid
+			if err := d.Append(5); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if want, got := []int64{5}, readAllDeltaRLE(t, d); !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if err := d.DeleteAt(1); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+			}
+		})
+	}
 }
 
 func TestDelta_SplitAt(t *testing.T) {
@@ -255,6 +421,136 @@ func TestDelta_SplitAt(t *testing.T) {
 	})
 }
 
+func TestDelta_Concat(t *testing.T) {
+	values := []int64{3, 4, 5, 9, 2, 2, 7}
+	for index := range values {
+		left, right := NewDelta(nil), NewDelta(nil)
+		for _, v := range values[:index] {
+			_ = left.Append(v)
+		}
+		for _, v := range values[index:] {
+			_ = right.Append(v)
+		}
+
+		got, err := left.Concat(right)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got := readAllDeltaRLE(t, got); !reflect.DeepEqual(got, values) {
+			t.Fatalf("got %v, want %v", got, values)
+		}
+
+		// the concatenated delta continues to accept appends
+		if err := got.Append(10); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := append(append([]int64(nil), values...), 10), readAllDeltaRLE(t, got); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestDelta_Null(t *testing.T) {
+	// check verifies d holds values with nulls at the indexes where nulls is set
+	check := func(t *testing.T, d *Delta, values []int64, nulls []bool) {
+		var token DeltaToken
+		var err error
+		for i, want := range values {
+			token, err = d.Next(token)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if got := token.Null; got != nulls[i] {
+				t.Fatalf("got %v, want %v at %v", got, nulls[i], i)
+			}
+			if got := token.Value; got != want {
+				t.Fatalf("got %v, want %v at %v", got, want, i)
+			}
+		}
+		if _, err := d.Next(token); !errors.Is(err, io.EOF) {
+			t.Fatalf("got %v; want %v", err, io.EOF)
+		}
+	}
+
+	testCases := map[string]int{
+		"plain":      0,
+		"skip index": 2,
+	}
+	for label, interval := range testCases {
+		t.Run(label, func(t *testing.T) {
+			var (
+				random = rand.New(rand.NewSource(1))
+				d      = NewDelta(nil)
+				values []int64
+				nulls  []bool
+			)
+			d.EnableSkipIndex(interval)
+			for i := 0; i < 500; i++ {
+				if len(values) == 0 || random.Intn(3) > 0 {
+					index, v, null := random.Intn(len(values)+1), random.Int63n(100), random.Intn(3) == 0
+					var err error
+					switch {
+					case null:
+						v, err = 0, d.InsertNullAt(int64(index))
+					case index == len(values) && random.Intn(2) == 0:
+						err = d.Append(v)
+					default:
+						err = d.InsertAt(int64(index), v)
+					}
+					if err != nil {
+						t.Fatalf("got %v; want nil", err)
+					}
+					values = append(values[:index], append([]int64{v}, values[index:]...)...)
+					nulls = append(nulls[:index], append([]bool{null}, nulls[index:]...)...)
+				} else {
+					index := random.Intn(len(values))
+					if err := d.DeleteAt(int64(index)); err != nil {
+						t.Fatalf("got %v; want nil", err)
+					}
+					values = append(values[:index], values[index+1:]...)
+					nulls = append(nulls[:index], nulls[index+1:]...)
+				}
+				check(t, d, values, nulls)
+
+				if len(values) > 0 {
+					index := random.Intn(len(values))
+					got, err := d.Get(int64(index))
+					if err != nil {
+						t.Fatalf("got %v; want nil", err)
+					}
+					if want := values[index]; got != want {
+						t.Fatalf("got %v, want %v", got, want)
+					}
+				}
+			}
+
+			for index := 0; index <= len(values); index += 7 {
+				left, right, err := d.SplitAt(int64(index))
+				if err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				check(t, left, values[:index], nulls[:index])
+				check(t, right, values[index:], nulls[index:])
+
+				joined, err := left.Concat(right)
+				if err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				check(t, joined, values, nulls)
+			}
+
+			// appends after trailing nulls are relative to the last value that is not null
+			if err := d.AppendNull(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if err := d.Append(42); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			check(t, d, append(values, 0, 42), append(nulls, true, false))
+		})
+	}
+}
+
 func readAllDeltaRLE(t *testing.T, d *Delta) []int64 {
 	var err error
 	var got []int64
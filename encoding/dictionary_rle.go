@@ -23,12 +23,19 @@ import (
 type DictionaryRLE struct {
 	dict *Plain
 	data *RLE
+
+	// last value appended along with its dictionary index.  entries are never removed from
+	// the dictionary so the cache remains valid across inserts.
+	last      []byte
+	lastIndex int64
+	lastOk    bool
 }
 
 type DictionaryRLEToken struct {
 	dict  map[int64][]byte
 	data  RLEToken
 	Value []byte
+	Null  bool // Null is set for absent values; Value is then nil
 }
 
 func NewDictionaryRLE(dict, data []byte) *DictionaryRLE {
@@ -38,6 +45,12 @@ func NewDictionaryRLE(dict, data []byte) *DictionaryRLE {
 	}
 }
 
+// EnableSkipIndex maintains a skip index over the rle encoded data so that reads and
+// writes seek part way into it.  An interval <= 0 disables the index.
+func (d *DictionaryRLE) EnableSkipIndex(interval int) {
+	d.data.EnableSkipIndex(interval)
+}
+
 func (d *DictionaryRLE) findOrInsert(value []byte, insert bool) (int64, error) {
 	var i int64
 	var token PlainToken
@@ -65,11 +78,15 @@ func (d *DictionaryRLE) findOrInsert(value []byte, insert bool) (int64, error) {
 	return int64(i), nil
 }
 
+// Get returns the value at index; nil if the value is null
 func (d *DictionaryRLE) Get(index int64) ([]byte, error) {
-	v, err := d.data.Get(index)
+	v, null, err := d.data.get(index)
 	if err != nil {
 		return nil, err
 	}
+	if null {
+		return nil, nil
+	}
 
 	var token PlainToken
 	for {
@@ -84,15 +101,61 @@ func (d *DictionaryRLE) Get(index int64) ([]byte, error) {
 	}
 }
 
-func (d *DictionaryRLE) InsertAt(index int64, value []byte) error {
-	v, err := d.findOrInsert(value, true)
-	if err != nil {
-		if err != io.EOF {
+// InsertAt inserts values, in order, at the given index
+func (d *DictionaryRLE) InsertAt(index int64, values ...[]byte) error {
+	indexes := make([]int64, 0, len(values))
+	for _, value := range values {
+		if d.lastOk && bytes.Equal(value, d.last) {
+			indexes = append(indexes, d.lastIndex)
+			continue
+		}
+
+		v, err := d.findOrInsert(value, true)
+		if err != nil {
+			if err != io.EOF {
+				return err
+			}
+		}
+		indexes = append(indexes, v)
+	}
+
+	return d.data.InsertAt(index, indexes...)
+}
+
+// InsertNullAt inserts a null value at index
+func (d *DictionaryRLE) InsertNullAt(index int64) error {
+	if err := d.data.InsertNullAt(index); err != nil {
+		return fmt.Errorf("unable to insert null dictionary value @%v: %w", index, err)
+	}
+	return nil
+}
+
+// DeleteAt removes the value at index.  The value remains in the dictionary.
+func (d *DictionaryRLE) DeleteAt(index int64) error {
+	if err := d.data.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete dictionary value @%v: %w", index, err)
+	}
+	return nil
+}
+
+// Append adds value after the last value
+func (d *DictionaryRLE) Append(value []byte) error {
+	if !d.lastOk || !bytes.Equal(value, d.last) {
+		v, err := d.findOrInsert(value, true)
+		if err != nil {
 			return err
 		}
+		d.last = append(d.last[:0], value...)
+		d.lastIndex = v
+		d.lastOk = true
 	}
 
-	return d.data.InsertAt(index, v)
+	return d.data.Append(d.lastIndex)
+}
+
+// AppendNull adds a null value after the last value
+func (d *DictionaryRLE) AppendNull() error {
+	return d.data.AppendNull()
 }
 
 func (d *DictionaryRLE) Lookup(value []byte) (int64, error) {
@@ -122,6 +185,14 @@ func (d *DictionaryRLE) Next(token DictionaryRLEToken) (DictionaryRLEToken, erro
 		return DictionaryRLEToken{}, err
 	}
 
+	if rleToken.Null {
+		return DictionaryRLEToken{
+			dict: token.dict,
+			data: rleToken,
+			Null: true,
+		}, nil
+	}
+
 	data, ok := token.dict[rleToken.Value]
 	if !ok {
 		return DictionaryRLEToken{}, fmt.Errorf("unable to find token for index, %v", rleToken.Value)
@@ -134,13 +205,45 @@ func (d *DictionaryRLE) Next(token DictionaryRLEToken) (DictionaryRLEToken, erro
 	}, nil
 }
 
+// Raw returns the underlying encoded bytes of the dictionary and the rle encoded data
+func (d *DictionaryRLE) Raw() (dict, data []byte) {
+	return d.dict.Raw(), d.data.Raw()
+}
+
 func (d *DictionaryRLE) RowCount() int {
 	return len(readAllDictionary2(d))
 }
 
+// Validate returns an error unless the data decodes to exactly rows values, each of which
+// is null or the index of an entry in the dictionary
+func (d *DictionaryRLE) Validate(rows int64) error {
+	var size int64
+	var token PlainToken
+	var err error
+	for {
+		token, err = d.dict.Next(token)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("invalid dictionary: %w", err)
+		}
+		size++
+	}
+
+	return d.data.validate(rows, func(value int64) error {
+		if value < 0 || value >= size {
+			return fmt.Errorf("dictionary index, %v, out of range", value)
+		}
+		return nil
+	})
+}
+
 func (d *DictionaryRLE) SplitAt(index int64) (left, right *DictionaryRLE, err error) {
 	left = NewDictionaryRLE(nil, nil)
 	right = NewDictionaryRLE(nil, nil)
+	left.EnableSkipIndex(d.data.skip.Interval())
+	right.EnableSkipIndex(d.data.skip.Interval())
 
 	var i int64
 	var token DictionaryRLEToken
@@ -153,22 +256,51 @@ func (d *DictionaryRLE) SplitAt(index int64) (left, right *DictionaryRLE, err er
 			return nil, nil, err
 		}
 
-		switch {
-		case i < index:
-			if err := left.InsertAt(i, token.Value); err != nil {
-				return nil, nil, err
-			}
-
-		default:
-			if err := right.InsertAt(i-index, token.Value); err != nil {
-				return nil, nil, err
-			}
+		target := left
+		if i >= index {
+			target = right
+		}
+		if token.Null {
+			err = target.AppendNull()
+		} else {
+			err = target.Append(token.Value)
+		}
+		if err != nil {
+			return nil, nil, err
 		}
 
 		i++
 	}
 }
 
+// Concat returns a DictionaryRLE holding the values of d followed by the values of that.
+// Values of that are re-encoded against the dictionary of d.
+func (d *DictionaryRLE) Concat(that *DictionaryRLE) (*DictionaryRLE, error) {
+	dict, data := d.Raw()
+	v := NewDictionaryRLE(append([]byte(nil), dict...), append([]byte(nil), data...))
+	v.EnableSkipIndex(d.data.skip.Interval())
+
+	var token DictionaryRLEToken
+	var err error
+	for {
+		token, err = that.Next(token)
+		if err != nil {
+			if err == io.EOF {
+				return v, nil
+			}
+			return nil, fmt.Errorf("unable to concat dictionary rle: %w", err)
+		}
+		if token.Null {
+			err = v.AppendNull()
+		} else {
+			err = v.Append(token.Value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to concat dictionary rle: %w", err)
+		}
+	}
+}
+
 func (d *DictionaryRLE) Size() int {
 	return d.dict.Size() + d.data.Size()
 }
@@ -64,6 +64,106 @@ func TestDictionaryRLE_InsertAt(t *testing.T) {
 	}
 }
 
+func TestDictionaryRLE_InsertAtMultiple(t *testing.T) {
+	d := NewDictionaryRLE(nil, nil)
+	d.EnableSkipIndex(2)
+
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+	if err := d.InsertAt(0, a, a, b); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.InsertAt(1, c, c, a); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.Append(b); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.InsertAt(6, b, c); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	want := [][]byte{a, c, c, a, a, b, b, c, b}
+	if got := readAllDictionary(t, d); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestDictionaryRLE_Append(t *testing.T) {
+	d := NewDictionaryRLE(nil, nil)
+	values := []string{"a", "a", "b", "a", "c", "c"}
+	for _, v := range values {
+		if err := d.Append([]byte(v)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	if err := d.InsertAt(0, []byte("c")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.Append([]byte("c")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	var got []string
+	for _, v := range readAllDictionary(t, d) {
+		got = append(got, string(v))
+	}
+	if want := []string{"c", "a", "a", "b", "a", "c", "c", "c"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDictionaryRLE_Validate(t *testing.T) {
+	d := NewDictionaryRLE(nil, nil)
+	for _, v := range []string{"a", "b", "a"} {
+		if err := d.Append([]byte(v)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	if err := d.Validate(3); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.Validate(4); err == nil {
+		t.Fatalf("got nil; want error")
+	}
+
+	// a single row referencing index 2 of a two entry dictionary
+	dict, _ := d.Raw()
+	if err := NewDictionaryRLE(dict, []byte{0x02, 0x04}).Validate(1); err == nil {
+		t.Fatalf("got nil; want error")
+	}
+}
+
+func TestDictionaryRLE_DeleteAt(t *testing.T) {
+	a, b := []byte("a"), []byte("b")
+	d := NewDictionaryRLE(nil, nil)
+	for _, v := range [][]byte{a, b, b, a} {
+		if err := d.Append(v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+
+	if err := d.DeleteAt(1); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := [][]byte{a, b, a}, readAllDictionary(t, d); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if err := d.DeleteAt(1); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.Append(b); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := [][]byte{a, a, b}, readAllDictionary(t, d); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if err := d.DeleteAt(3); err == nil {
+		t.Fatalf("got nil; want err")
+	}
+}
+
 func TestDictionaryRLE_SplitAt(t *testing.T) {
 	t.Run("middle", func(t *testing.T) {
 		d := NewDictionaryRLE(nil, nil)
@@ -109,6 +209,88 @@ func TestDictionaryRLE_SplitAt(t *testing.T) {
 	})
 }
 
+func TestDictionaryRLE_Concat(t *testing.T) {
+	a, b, c := []byte("a"), []byte("b"), []byte("c")
+	left, right := NewDictionaryRLE(nil, nil), NewDictionaryRLE(nil, nil)
+	for _, v := range [][]byte{a, b} {
+		if err := left.Append(v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	// the dictionary of right assigns different indexes to the same values
+	for _, v := range [][]byte{c, b, a} {
+		if err := right.Append(v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+
+	got, err := left.Concat(right)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := [][]byte{a, b, c, b, a}, readAllDictionary(t, got); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := [][]byte{a, b}, readAllDictionary(t, left); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDictionaryRLE_Null(t *testing.T) {
+	a, b := []byte("a"), []byte("b")
+	d := NewDictionaryRLE(nil, nil)
+	if err := d.Append(a); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.AppendNull(); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.Append(b); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := d.InsertNullAt(0); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	want := [][]byte{nil, a, nil, b}
+	if got := readAllDictionary(t, d); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, want := range want {
+		got, err := d.Get(int64(i))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	var token DictionaryRLEToken
+	var err error
+	for i, want := range []bool{true, false, true, false} {
+		token, err = d.Next(token)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got := token.Null; got != want {
+			t.Fatalf("got %v, want %v at %v", got, want, i)
+		}
+	}
+
+	left, right, err := d.SplitAt(2)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	joined, err := left.Concat(right)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got := readAllDictionary(t, joined); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
 func readAllDictionary(t *testing.T, d *DictionaryRLE) [][]byte {
 	var got [][]byte
 	var token DictionaryRLEToken
@@ -15,12 +15,14 @@
 package encoding
 
 import (
+	"fmt"
 	"io"
 )
 
 type Plain struct {
 	buffer  []byte
 	rawType RawType
+	skip    *skipIndex // optional index of value positions; nil when disabled
 }
 
 type PlainToken struct {
@@ -36,9 +38,45 @@ func NewPlain(rawType RawType, buffer []byte) *Plain {
 	}
 }
 
-func (p *Plain) InsertAt(index int64, value Value) error {
-	var i int64
-	var pos int
+// EnableSkipIndex maintains the byte position of every interval values so that writes
+// seek part way into the buffer rather than scanning from the start.  An interval <= 0
+// disables the index.
+func (p *Plain) EnableSkipIndex(interval int) {
+	p.skip = newSkipIndex(interval)
+}
+
+// seek returns the row and byte position of a value at or before index
+func (p *Plain) seek(index int64) skipEntry {
+	return p.skip.find(index, p.buildSkip)
+}
+
+func (p *Plain) buildSkip(interval int) []skipEntry {
+	var entries []skipEntry
+	var row int64
+	for pos := 0; pos < len(p.buffer); row++ {
+		if row > 0 && row%int64(interval) == 0 {
+			entries = append(entries, skipEntry{Row: row, Pos: pos})
+		}
+		got, err := ReadValue(p.rawType, p.buffer[pos:])
+		if err != nil || got.Length() <= 0 {
+			break
+		}
+		pos += got.Length()
+	}
+	return entries
+}
+
+// derive returns a Plain over buffer with the same raw type and skip index interval as p
+func (p *Plain) derive(buffer []byte) *Plain {
+	v := NewPlain(p.rawType, buffer)
+	v.skip = newSkipIndex(p.skip.Interval())
+	return v
+}
+
+// InsertAt inserts values, in order, at the given index
+func (p *Plain) InsertAt(index int64, values ...Value) error {
+	entry := p.seek(index)
+	i, pos := entry.Row, entry.Pos
 	for pos < len(p.buffer) {
 		if i == index {
 			break
@@ -54,14 +92,64 @@ func (p *Plain) InsertAt(index int64, value Value) error {
 	}
 
 	if i == index {
-		p.buffer = shift(p.buffer, pos, value.Length())
-		value.Copy(p.buffer[pos:])
+		var length int
+		for _, value := range values {
+			length += value.Length()
+		}
+
+		p.buffer = shift(p.buffer, pos, length)
+		p.skip.adjust(pos, length, int64(len(values)), 0, len(p.buffer))
+		for _, value := range values {
+			value.Copy(p.buffer[pos:])
+			pos += value.Length()
+		}
 		return nil
 	}
 
 	return io.ErrUnexpectedEOF
 }
 
+// DeleteAt removes the value at index
+func (p *Plain) DeleteAt(index int64) error {
+	if index < 0 {
+		return fmt.Errorf("unable to delete value @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+
+	entry := p.seek(index)
+	i, pos := entry.Row, entry.Pos
+	for pos < len(p.buffer) {
+		got, err := ReadValue(p.rawType, p.buffer[pos:])
+		if err != nil {
+			return fmt.Errorf("unable to delete value @%v: %w", index, err)
+		}
+
+		if i == index {
+			length := got.Length()
+			p.buffer = unshift(p.buffer, pos, length)
+			p.skip.adjust(pos, -length, -1, 0, len(p.buffer))
+			return nil
+		}
+
+		i++
+		pos += got.Length()
+	}
+	return fmt.Errorf("unable to delete value @%v: %w", index, io.ErrUnexpectedEOF)
+}
+
+// Append adds values after the last value without scanning the buffer
+func (p *Plain) Append(values ...Value) error {
+	pos := len(p.buffer)
+	for _, value := range values {
+		buffer, err := value.Append(p.buffer)
+		if err != nil {
+			return err
+		}
+		p.buffer = buffer
+	}
+	p.skip.adjust(pos, 0, 0, 0, len(p.buffer))
+	return nil
+}
+
 func (p *Plain) Next(token PlainToken) (PlainToken, error) {
 	if token.pos >= len(p.buffer) {
 		return PlainToken{}, io.EOF
@@ -85,15 +173,15 @@ func (p *Plain) Next(token PlainToken) (PlainToken, error) {
 }
 
 func (p *Plain) SplitAt(index int64) (left, right *Plain, err error) {
-	var i int64
-	var pos int
+	entry := p.seek(index)
+	i, pos := entry.Row, entry.Pos
 	for pos < len(p.buffer) {
 		if i == index {
 			rb := make([]byte, 0, cap(p.buffer))
 			rb = append(rb, p.buffer[pos:]...)
 			lb := p.buffer[0:pos]
 
-			return NewPlain(p.rawType, lb), NewPlain(p.rawType, rb), nil
+			return p.derive(lb), p.derive(rb), nil
 		}
 
 		got, err := ReadValue(p.rawType, p.buffer[pos:])
@@ -106,16 +194,61 @@ func (p *Plain) SplitAt(index int64) (left, right *Plain, err error) {
 	}
 
 	if i == index {
-		return p, NewPlain(p.rawType, nil), nil
+		return p, p.derive(nil), nil
 	}
 
 	return nil, nil, io.ErrUnexpectedEOF
 }
 
+// Concat returns a Plain holding the values of p followed by the values of that
+func (p *Plain) Concat(that *Plain) (*Plain, error) {
+	if p.rawType != that.rawType {
+		return nil, fmt.Errorf("unable to concat plain: raw type mismatch, %v != %v", p.rawType, that.rawType)
+	}
+
+	buffer := make([]byte, 0, len(p.buffer)+len(that.buffer))
+	buffer = append(buffer, p.buffer...)
+	buffer = append(buffer, that.buffer...)
+	return p.derive(buffer), nil
+}
+
+// Raw returns the underlying encoded bytes
+func (p *Plain) Raw() []byte {
+	return p.buffer
+}
+
+// RawType returns the raw type of the values held by Plain
+func (p *Plain) RawType() RawType {
+	return p.rawType
+}
+
 func (p *Plain) Size() int {
 	return len(p.buffer)
 }
 
+// Validate returns an error unless the buffer decodes to exactly rows values
+func (p *Plain) Validate(rows int64) error {
+	var n int64
+	var token PlainToken
+	var err error
+	for {
+		token, err = p.Next(token)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("invalid value at row, %v: %w", n, err)
+		}
+		if n++; n > rows {
+			return fmt.Errorf("invalid row count: more than %v rows", rows)
+		}
+	}
+	if n != rows {
+		return fmt.Errorf("invalid row count: got %v, want %v", n, rows)
+	}
+	return nil
+}
+
 func (p *Plain) RowCount() int {
 	var err error
 	var got []Value
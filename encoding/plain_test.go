@@ -15,7 +15,10 @@
 package encoding
 
 import (
+	"errors"
 	"io"
+	"math/rand"
+	"reflect"
 	"testing"
 )
 
@@ -124,6 +127,26 @@ func TestPlain_InsertAt(t *testing.T) {
 			t.Fatalf("got %v, want %v", got, want)
 		}
 	})
+
+	t.Run("many", func(t *testing.T) {
+		p := NewPlain(RawTypeByteArray, nil)
+		if err := p.InsertAt(0, StringValue("a"), StringValue("d")); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := p.InsertAt(1, StringValue("b"), StringValue("c")); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		got := readAllValues(t, p)
+		if got, want := got, 4; len(got) != want {
+			t.Fatalf("got %v; want %v", len(got), want)
+		}
+		for i, want := range []string{"a", "b", "c", "d"} {
+			if got := string(got[i].Bytes); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	})
 }
 
 func TestPlain_InsertAtVarInt(t *testing.T) {
@@ -254,6 +277,153 @@ func TestPlain_InsertAtVarInt(t *testing.T) {
 	})
 }
 
+func TestPlain_Append(t *testing.T) {
+	p := NewPlain(RawTypeByteArray, nil)
+	if err := p.InsertAt(0, StringValue("a")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := p.Append(StringValue("b"), StringValue("c")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	got := readAllValues(t, p)
+	if got, want := got, 3; len(got) != want {
+		t.Fatalf("got %v; want %v", len(got), want)
+	}
+	for i, want := range []string{"a", "b", "c"} {
+		if got := string(got[i].Bytes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if err := p.Append(Value{}); err == nil {
+		t.Fatalf("got nil; want err")
+	}
+	if want, got := 3, p.RowCount(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPlain_SkipIndex(t *testing.T) {
+	var (
+		random = rand.New(rand.NewSource(1))
+		got    = NewPlain(RawTypeVarInt, nil)
+		want   []int64
+	)
+	got.EnableSkipIndex(4)
+
+	for i := 0; i < 1000; i++ {
+		index, v := random.Intn(len(want)+1), random.Int63n(1000)
+		if random.Intn(4) == 0 {
+			if err := got.Append(Int64Value(v)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want, v)
+			continue
+		}
+
+		if err := got.InsertAt(int64(index), Int64Value(v)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		want = append(want[:index], append([]int64{v}, want[index:]...)...)
+	}
+
+	int64s := func(p *Plain) []int64 {
+		var values []int64
+		for _, value := range readAllValues(t, p) {
+			values = append(values, value.Int)
+		}
+		return values
+	}
+	if got := int64s(got); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	left, right, err := got.SplitAt(500)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got := append(int64s(left), int64s(right)...); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestPlain_Concat(t *testing.T) {
+	left := NewPlain(RawTypeByteArray, nil)
+	right := NewPlain(RawTypeByteArray, nil)
+	if err := left.Append(StringValue("a"), StringValue("b")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := right.Append(StringValue("c")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	got, err := left.Concat(right)
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	values := readAllValues(t, got)
+	if want, got := 3, len(values); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, want := range []string{"a", "b", "c"} {
+		if got := string(values[i].Bytes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if _, err := left.Concat(NewPlain(RawTypeVarInt, nil)); err == nil {
+		t.Fatalf("got nil; want err")
+	}
+}
+
+func TestPlain_DeleteAt(t *testing.T) {
+	testCases := map[string]int{
+		"plain":      0,
+		"skip index": 2,
+	}
+	for label, interval := range testCases {
+		t.Run(label, func(t *testing.T) {
+			var (
+				random = rand.New(rand.NewSource(1))
+				p      = NewPlain(RawTypeVarInt, nil)
+				want   []int64
+			)
+			p.EnableSkipIndex(interval)
+			for i := int64(0); i < 100; i++ {
+				v := random.Int63n(1000)
+				if err := p.Append(Int64Value(v)); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				want = append(want, v)
+			}
+
+			for len(want) > 0 {
+				index := random.Intn(len(want))
+				if err := p.DeleteAt(int64(index)); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				want = append(want[:index], want[index+1:]...)
+
+				var got []int64
+				for _, value := range readAllValues(t, p) {
+					got = append(got, value.Int)
+				}
+				if len(want) > 0 && !reflect.DeepEqual(got, want) {
+					t.Fatalf("got %v, want %v", got, want)
+				}
+			}
+
+			if want, got := 0, p.Size(); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if err := p.DeleteAt(0); !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+			}
+		})
+	}
+}
+
 func TestPlain_SplitAt(t *testing.T) {
 	makeItem := func() *Plain {
 		base := NewPlain(RawTypeVarInt, nil)
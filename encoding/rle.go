@@ -23,6 +23,9 @@ import (
 
 type RLE struct {
 	buffer []byte
+	tail   int        // byte position of the last run, valid when tailOk
+	tailOk bool       // tailOk is cleared by any write other than Append
+	skip   *skipIndex // optional index of run positions; nil when disabled
 }
 
 type RLEToken struct {
@@ -30,6 +33,7 @@ type RLEToken struct {
 	Repeat int
 	Index  int
 	Value  int64
+	Null   bool // Null is set for absent values; Value is then 0
 }
 
 type rleBlock struct {
@@ -37,12 +41,18 @@ type rleBlock struct {
 	RepeatLength int
 	Value        int64
 	Length       int
+	Null         bool // Null runs are encoded as a zero repeat followed by the run length
+}
+
+// same returns true if the run holds value, or nulls when null is set
+func (b rleBlock) same(value int64, null bool) bool {
+	return b.Null == null && (null || b.Value == value)
 }
 
 type rleBuffer struct {
-	Repeat       [8]byte
+	Repeat       [binary.MaxVarintLen64]byte
 	RepeatLength int
-	Value        [8]byte
+	Value        [binary.MaxVarintLen64]byte
 	ValueLength  int
 }
 
@@ -60,11 +70,54 @@ func NewRLE(buffer []byte) *RLE {
 	return &RLE{buffer: buffer}
 }
 
+// EnableSkipIndex maintains the byte position of every interval runs so that reads and
+// writes seek part way into the buffer rather than scanning from the start.  An interval
+// <= 0 disables the index.
+func (r *RLE) EnableSkipIndex(interval int) {
+	r.skip = newSkipIndex(interval)
+}
+
+// seek returns the row, byte position, and preceding sum of a run beginning at or before
+// index
+func (r *RLE) seek(index int64) skipEntry {
+	return r.skip.find(index, r.buildSkip)
+}
+
+func (r *RLE) buildSkip(interval int) []skipEntry {
+	var entries []skipEntry
+	var row, sum int64
+	for n, pos := 0, 0; pos < len(r.buffer); n++ {
+		block, err := r.readAt(pos)
+		if err != nil || block.Length <= 0 {
+			break
+		}
+		if n > 0 && n%interval == 0 {
+			entries = append(entries, skipEntry{Row: row, Pos: pos, Sum: sum})
+		}
+		row += block.Repeat
+		sum += block.Repeat * block.Value
+		pos += block.Length
+	}
+	return entries
+}
+
 func (r *RLE) readAt(pos int) (rleBlock, error) {
 	var (
 		repeat, repeatLength = binary.Varint(r.buffer[pos:])
 		value, valueLength   = binary.Varint(r.buffer[pos+repeatLength:])
 	)
+	if repeatLength <= 0 || valueLength <= 0 {
+		return rleBlock{}, io.ErrUnexpectedEOF
+	}
+
+	if repeat == 0 {
+		return rleBlock{
+			Repeat:       value,
+			RepeatLength: repeatLength,
+			Length:       repeatLength + valueLength,
+			Null:         true,
+		}, nil
+	}
 
 	return rleBlock{
 		Repeat:       repeat,
@@ -74,32 +127,43 @@ func (r *RLE) readAt(pos int) (rleBlock, error) {
 	}, nil
 }
 
-func (r *RLE) writeAtWithShift(pos int, repeat, value int64) int {
-	buf := rleEncode(repeat, value)
+func (r *RLE) writeAtWithShift(pos int, repeat, value int64, null bool) int {
+	r.tailOk = false
+	buf := rleEncodeBlock(repeat, value, null)
 
 	// shift bytes over to make room
 	r.buffer = shift(r.buffer, pos, buf.RepeatLength+buf.ValueLength)
 
 	copy(r.buffer[pos:], buf.Repeat[:buf.RepeatLength])
 	copy(r.buffer[pos+buf.RepeatLength:], buf.Value[:buf.ValueLength])
+	r.skip.adjust(pos, buf.Length(), repeat, repeat*value, len(r.buffer))
 	return buf.RepeatLength + buf.ValueLength
 }
 
-func (r *RLE) writeAt(pos int, repeat, value int64) int {
-	buf := rleEncode(repeat, value)
-
-	copy(r.buffer[pos:], buf.Repeat[:buf.RepeatLength])
-	copy(r.buffer[pos+buf.RepeatLength:], buf.Value[:buf.ValueLength])
-	return buf.RepeatLength + buf.ValueLength
+// rewrite replaces block, the run at pos, with a run of the same value repeated repeat times
+func (r *RLE) rewrite(pos int, block rleBlock, repeat int64) {
+	r.tailOk = false
+	buf := rleEncodeBlock(repeat, block.Value, block.Null)
+
+	delta := buf.Length() - block.Length
+	switch {
+	case delta > 0:
+		r.buffer = shift(r.buffer, pos, delta)
+	case delta < 0:
+		r.buffer = unshift(r.buffer, pos, -delta)
+	}
+	buf.Copy(r.buffer[pos:])
+	r.skip.adjust(pos, delta, repeat-block.Repeat, (repeat-block.Repeat)*block.Value, len(r.buffer))
 }
 
 func (r *RLE) DeleteAt(index int64) error {
+	r.tailOk = false
 	if index < 0 {
 		return fmt.Errorf("rle delete failed: %w", io.ErrUnexpectedEOF)
 	}
 
-	var i int64
-	var pos int
+	entry := r.seek(index)
+	i, pos := entry.Row, entry.Pos
 	for pos < len(r.buffer) {
 		block, err := r.readAt(pos)
 		if err != nil {
@@ -110,20 +174,11 @@ func (r *RLE) DeleteAt(index int64) error {
 			// run length 1
 			if block.Repeat == 1 {
 				r.buffer = unshift(r.buffer, pos, block.Length)
+				r.skip.adjust(pos, -block.Length, -1, -block.Value, len(r.buffer))
 				return nil
 			}
 
-			// shortened run length has same footprint
-			repeat, repeatLength := putVarInt(block.Repeat - 1)
-			if repeatLength == block.RepeatLength {
-				copy(r.buffer[pos:], repeat[0:repeatLength])
-				return nil
-			}
-
-			// shrink value
-			r.buffer = unshift(r.buffer, pos+repeatLength, block.RepeatLength-repeatLength)
-			copy(r.buffer[pos:], repeat[0:repeatLength])
-
+			r.rewrite(pos, block, block.Repeat-1)
 			return nil
 		}
 
@@ -133,28 +188,101 @@ func (r *RLE) DeleteAt(index int64) error {
 	return fmt.Errorf("unable to delete @%v: %w", index, io.ErrUnexpectedEOF)
 }
 
+// Get returns the value at index; 0 if the value is null
 func (r *RLE) Get(index int64) (int64, error) {
-	var i int64
-	var pos int
+	v, _, err := r.get(index)
+	return v, err
+}
+
+// IsNull returns true if the value at index is null
+func (r *RLE) IsNull(index int64) (bool, error) {
+	_, null, err := r.get(index)
+	return null, err
+}
+
+func (r *RLE) get(index int64) (int64, bool, error) {
+	entry := r.seek(index)
+	i, pos := entry.Row, entry.Pos
 	for pos < len(r.buffer) {
 		block, err := r.readAt(pos)
 		if err != nil {
-			return 0, fmt.Errorf("unable to insert value at index, %v: %w", index, err)
+			return 0, false, fmt.Errorf("unable to get value at index, %v: %w", index, err)
 		}
 
-		if index >= i || index <= i+block.Repeat {
-			return block.Value, nil
+		if index >= i && index < i+block.Repeat {
+			return block.Value, block.Null, nil
 		}
 
 		i += block.Repeat
 		pos += block.Length
 	}
-	return 0, io.ErrUnexpectedEOF
+	return 0, false, io.ErrUnexpectedEOF
 }
 
-func (r *RLE) InsertAt(index, v int64) error {
-	var i int64
-	var pos int
+// nextValue returns the index of the first value at or after index that is not null
+func (r *RLE) nextValue(index int64) (int64, bool, error) {
+	entry := r.seek(index)
+	i, pos := entry.Row, entry.Pos
+	for pos < len(r.buffer) {
+		block, err := r.readAt(pos)
+		if err != nil {
+			return 0, false, err
+		}
+
+		if !block.Null && index < i+block.Repeat {
+			if index < i {
+				return i, true, nil
+			}
+			return index, true, nil
+		}
+
+		i += block.Repeat
+		pos += block.Length
+	}
+	return 0, false, nil
+}
+
+// InsertAt inserts values, in order, at the given index.  Multiple values are written in
+// a single pass over the buffer rather than one pass per value.
+func (r *RLE) InsertAt(index int64, values ...int64) error {
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return r.insertAt(index, values[0], false)
+	}
+
+	left, right, err := r.SplitAt(index)
+	if err != nil {
+		return fmt.Errorf("unable to insert %v values at index, %v: %w", len(values), index, err)
+	}
+	for _, v := range values {
+		if err := left.Append(v); err != nil {
+			return fmt.Errorf("unable to insert %v values at index, %v: %w", len(values), index, err)
+		}
+	}
+	joined, err := left.Concat(right)
+	if err != nil {
+		return fmt.Errorf("unable to insert %v values at index, %v: %w", len(values), index, err)
+	}
+
+	r.buffer = joined.buffer
+	r.tailOk = false
+	r.skip = newSkipIndex(r.skip.Interval())
+	return nil
+}
+
+// InsertNullAt inserts a null value at index
+func (r *RLE) InsertNullAt(index int64) error {
+	return r.insertAt(index, 0, true)
+}
+
+func (r *RLE) insertAt(index, v int64, null bool) error {
+	r.tailOk = false
+
+	// seek before index so a run ending at index may be extended
+	entry := r.seek(index - 1)
+	i, pos := entry.Row, entry.Pos
 	for pos < len(r.buffer) {
 		block, err := r.readAt(pos)
 		if err != nil {
@@ -162,33 +290,26 @@ func (r *RLE) InsertAt(index, v int64) error {
 		}
 
 		switch {
-		case v == block.Value && index >= i && index <= i+block.Repeat:
-			repeat, repeatLength := putVarInt(block.Repeat + 1)
-			if delta := repeatLength - block.RepeatLength; delta == 0 {
-				// repeat length unchanged, just write over repeat
-				copy(r.buffer[pos:], repeat[:repeatLength])
-			} else {
-				// repeat encoding length increased, shift contents and write both
-				r.buffer = shift(r.buffer, pos, delta)
-				r.writeAt(pos, block.Repeat+1, block.Value)
-			}
+		case block.same(v, null) && index >= i && index <= i+block.Repeat:
+			r.rewrite(pos, block, block.Repeat+1)
 			return nil
 
 		case index == i:
-			r.writeAtWithShift(pos, 1, v)
+			r.writeAtWithShift(pos, 1, v, null)
 			return nil
 
 		case index < i+block.Repeat:
 			var (
 				beforeN = index - i
-				before  = rleEncode(beforeN, block.Value)
-				buf     = rleEncode(1, v)
-				after   = rleEncode(block.Repeat-beforeN, block.Value)
+				before  = rleEncodeBlock(beforeN, block.Value, block.Null)
+				buf     = rleEncodeBlock(1, v, null)
+				after   = rleEncodeBlock(block.Repeat-beforeN, block.Value, block.Null)
 				delta   = before.Length() + buf.Length() + after.Length() - block.Length
 			)
 
 			// make space
 			r.buffer = shift(r.buffer, pos, delta)
+			r.skip.adjust(pos, delta, 1, v, len(r.buffer))
 
 			n := before.Copy(r.buffer[pos:])
 			pos += n
@@ -206,11 +327,66 @@ func (r *RLE) InsertAt(index, v int64) error {
 	}
 
 	// new record
-	r.writeAtWithShift(pos, 1, v)
+	r.writeAtWithShift(pos, 1, v, null)
+
+	return nil
+}
+
+// Append adds v after the last value.  The position of the last run is cached so that
+// successive appends, e.g. sequential typing, need not scan the buffer.
+func (r *RLE) Append(v int64) error {
+	return r.append(v, false)
+}
+
+// AppendNull adds a null value after the last value
+func (r *RLE) AppendNull() error {
+	return r.append(0, true)
+}
+
+func (r *RLE) append(v int64, null bool) error {
+	if !r.tailOk {
+		r.tail = r.lastRun()
+		r.tailOk = true
+	}
+
+	if r.tail < len(r.buffer) {
+		block, err := r.readAt(r.tail)
+		if err != nil {
+			return fmt.Errorf("unable to append value, %v: %w", v, err)
+		}
+		if block.same(v, null) {
+			// the last run sits at the end of the buffer so it may be rewritten in place
+			buf := rleEncodeBlock(block.Repeat+1, v, null)
+			r.buffer = r.buffer[:r.tail]
+			r.buffer = append(r.buffer, buf.Repeat[:buf.RepeatLength]...)
+			r.buffer = append(r.buffer, buf.Value[:buf.ValueLength]...)
+			return nil
+		}
+	}
 
+	buf := rleEncodeBlock(1, v, null)
+	r.tail = len(r.buffer)
+	r.buffer = append(r.buffer, buf.Repeat[:buf.RepeatLength]...)
+	r.buffer = append(r.buffer, buf.Value[:buf.ValueLength]...)
+	r.skip.adjust(r.tail, 0, 0, 0, len(r.buffer))
 	return nil
 }
 
+// lastRun returns the byte position of the last run or len(buffer) if empty
+func (r *RLE) lastRun() int {
+	last := len(r.buffer)
+	for pos := 0; pos < len(r.buffer); {
+		block, err := r.readAt(pos)
+		if err != nil || block.Length <= 0 {
+			break
+		}
+		last = pos
+		pos += block.Length
+	}
+	return last
+}
+
+// Int64 returns all values; null values are returned as 0
 func (r *RLE) Int64() ([]int64, error) {
 	var pos int
 	var values []int64
@@ -222,6 +398,9 @@ func (r *RLE) Int64() ([]int64, error) {
 			value, length := binary.Varint(r.buffer[pos:])
 			pos += length
 
+			if repeat == 0 {
+				repeat, value = value, 0 // null run
+			}
 			for i := int64(0); i < repeat; i++ {
 				values = append(values, value)
 			}
@@ -241,6 +420,7 @@ func (r *RLE) Next(token RLEToken) (RLEToken, error) {
 			Index:  token.Index + 1,
 			Repeat: token.Repeat - 1,
 			Value:  token.Value,
+			Null:   token.Null,
 		}, nil
 	}
 	if token.Pos >= len(r.buffer) {
@@ -257,6 +437,11 @@ func (r *RLE) Next(token RLEToken) (RLEToken, error) {
 
 	value, length := binary.Varint(r.buffer[pos:])
 
+	null := repeat == 0
+	if null {
+		repeat, value = value, 0
+	}
+
 	index := token.Index + 1
 	if token.Pos == 0 {
 		index = 0
@@ -268,29 +453,78 @@ func (r *RLE) Next(token RLEToken) (RLEToken, error) {
 		Index:  index,
 		Repeat: int(repeat) - 1,
 		Value:  value,
+		Null:   null,
 	}, nil
 }
 
+// Raw returns the underlying encoded bytes
+func (r *RLE) Raw() []byte {
+	return r.buffer
+}
+
+// RowCount returns the number of values encoded by summing the run lengths
 func (r *RLE) RowCount() int {
-	return len(readAllRLE2(r.buffer))
+	var n int64
+	for pos := 0; pos < len(r.buffer); {
+		block, err := r.readAt(pos)
+		if err != nil {
+			break
+		}
+		n += block.Repeat
+		pos += block.Length
+	}
+	return int(n)
 }
 
 func (r *RLE) Size() int {
 	return len(r.buffer)
 }
 
+// Validate returns an error unless the buffer decodes to exactly rows values
+func (r *RLE) Validate(rows int64) error {
+	return r.validate(rows, nil)
+}
+
+// validate checks the buffer holds exactly rows values along with the value of each
+// non-null run against check, if provided
+func (r *RLE) validate(rows int64, check func(value int64) error) error {
+	var n int64
+	for pos := 0; pos < len(r.buffer); {
+		block, err := r.readAt(pos)
+		if err != nil {
+			return fmt.Errorf("invalid run at byte, %v: %w", pos, err)
+		}
+		if block.Repeat <= 0 {
+			return fmt.Errorf("invalid run at byte, %v: repeat, %v", pos, block.Repeat)
+		}
+		if n += block.Repeat; n > rows {
+			return fmt.Errorf("invalid row count: more than %v rows", rows)
+		}
+		if check != nil && !block.Null {
+			if err := check(block.Value); err != nil {
+				return fmt.Errorf("invalid run at byte, %v: %w", pos, err)
+			}
+		}
+		pos += block.Length
+	}
+	if n != rows {
+		return fmt.Errorf("invalid row count: got %v, want %v", n, rows)
+	}
+	return nil
+}
+
 func (r *RLE) SplitAt(index int64) (left, right *RLE, err error) {
 	if index < 0 {
 		return nil, nil, fmt.Errorf("unable to split on negative index")
 	}
 
-	var i int64
-	var pos int
+	entry := r.seek(index)
+	i, pos := entry.Row, entry.Pos
 	for pos < len(r.buffer) {
-		var (
-			repeat, repeatLength = binary.Varint(r.buffer[pos:])
-			value, valueLength   = binary.Varint(r.buffer[pos+repeatLength:])
-		)
+		block, err := r.readAt(pos)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to split at index, %v: %w", index, err)
+		}
 
 		switch {
 		case index == i: // on run boundary
@@ -298,33 +532,73 @@ func (r *RLE) SplitAt(index int64) (left, right *RLE, err error) {
 			rb = append(rb, r.buffer[pos:]...)
 			lb := make([]byte, 0, len(r.buffer))
 			lb = append(lb, r.buffer[0:pos]...)
-			return NewRLE(lb), NewRLE(rb), nil
+			return r.derive(lb), r.derive(rb), nil
 
-		case index > i && index < i+repeat: // in the middle
+		case index > i && index < i+block.Repeat: // in the middle
 			rb := make([]byte, 0, cap(r.buffer))
-			right := NewRLE(rb)
-			right.writeAtWithShift(0, repeat-(index-i), value)
-			right.buffer = append(right.buffer, r.buffer[pos+repeatLength+valueLength:]...)
+			right := r.derive(rb)
+			right.writeAtWithShift(0, block.Repeat-(index-i), block.Value, block.Null)
+			right.buffer = append(right.buffer, r.buffer[pos+block.Length:]...)
 
 			lb := make([]byte, 0, cap(r.buffer))
 			lb = append(lb, r.buffer[0:pos]...)
-			left := NewRLE(lb)
-			left.writeAtWithShift(pos, index-i, value)
+			left := r.derive(lb)
+			left.writeAtWithShift(pos, index-i, block.Value, block.Null)
 
 			return left, right, nil
 		}
 
-		i += repeat
-		pos += repeatLength + valueLength
+		i += block.Repeat
+		pos += block.Length
 	}
 
 	if i == index {
-		return r, NewRLE(nil), nil
+		return r, r.derive(nil), nil
 	}
 
 	return nil, nil, io.ErrUnexpectedEOF
 }
 
+// Concat returns an RLE holding the values of r followed by the values of that.  Runs of
+// the same value either side of the boundary are joined.
+func (r *RLE) Concat(that *RLE) (*RLE, error) {
+	buffer := make([]byte, 0, len(r.buffer)+len(that.buffer))
+	v := r.derive(append(buffer, r.buffer...))
+	if len(that.buffer) == 0 {
+		return v, nil
+	}
+
+	if len(r.buffer) > 0 {
+		tail := r.lastRun()
+		last, err := r.readAt(tail)
+		if err != nil {
+			return nil, fmt.Errorf("unable to concat rle: %w", err)
+		}
+		first, err := that.readAt(0)
+		if err != nil {
+			return nil, fmt.Errorf("unable to concat rle: %w", err)
+		}
+
+		if last.same(first.Value, first.Null) {
+			buf := rleEncodeBlock(last.Repeat+first.Repeat, last.Value, last.Null)
+			v.buffer = append(v.buffer[:tail], buf.Repeat[:buf.RepeatLength]...)
+			v.buffer = append(v.buffer, buf.Value[:buf.ValueLength]...)
+			v.buffer = append(v.buffer, that.buffer[first.Length:]...)
+			return v, nil
+		}
+	}
+
+	v.buffer = append(v.buffer, that.buffer...)
+	return v, nil
+}
+
+// derive returns an RLE over buffer with the same skip index interval as r
+func (r *RLE) derive(buffer []byte) *RLE {
+	v := NewRLE(buffer)
+	v.skip = newSkipIndex(r.skip.Interval())
+	return v
+}
+
 // Translate returns actual index within the page for the requested element accounting
 // for deletes.
 //
@@ -360,6 +634,14 @@ func (r *RLE) Translate(index int64, isDelete func(opType int64) bool) (int64, e
 	return actualIndex, nil
 }
 
+// rleEncodeBlock encodes a run of repeat values or, if null is set, repeat nulls
+func rleEncodeBlock(repeat, value int64, null bool) rleBuffer {
+	if null {
+		return rleEncode(0, repeat)
+	}
+	return rleEncode(repeat, value)
+}
+
 func rleEncode(repeat, value int64) rleBuffer {
 	rb, rn := putVarInt(repeat)
 	vb, vn := putVarInt(value)
@@ -371,26 +653,3 @@ func rleEncode(repeat, value int64) rleBuffer {
 		ValueLength:  vn,
 	}
 }
-
-func readAllRLE2(buffer []byte) []int64 {
-	var pos int
-	var values []int64
-	if len(buffer) > 0 {
-		for {
-			repeat, length := binary.Varint(buffer[pos:])
-			pos += length
-
-			value, length := binary.Varint(buffer[pos:])
-			pos += length
-
-			for i := int64(0); i < repeat; i++ {
-				values = append(values, value)
-			}
-
-			if pos == len(buffer) {
-				break
-			}
-		}
-	}
-	return values
-}
@@ -18,6 +18,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"io"
+	"math"
+	"math/rand"
 	"reflect"
 	"testing"
 )
@@ -269,6 +271,233 @@ func TestRLE_InsertAt(t *testing.T) {
 			t.Fatalf("got %v; want %v", len(got), want)
 		}
 	})
+
+	t.Run("multiple values", func(t *testing.T) {
+		var (
+			rng  = rand.New(rand.NewSource(1))
+			r    = NewRLE(nil)
+			want []int64
+		)
+		r.EnableSkipIndex(4)
+		for i := 0; i < 200; i++ {
+			index := rng.Intn(len(want) + 1)
+			values := make([]int64, rng.Intn(4)+1)
+			for j := range values {
+				values[j] = rng.Int63n(3)
+			}
+			if err := r.InsertAt(int64(index), values...); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:index], append(values, want[index:]...)...)
+
+			if got := MustInt64(r.Int64()); !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+
+		if err := r.InsertAt(int64(len(want)+1), 1, 2); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+
+}
+
+func TestRLE_Append(t *testing.T) {
+	var (
+		got  = NewRLE(nil)
+		want = NewRLE(nil)
+		n    int64
+	)
+	appendValue := func(v int64) {
+		if err := got.Append(v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := want.InsertAt(n, v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		n++
+	}
+
+	// a run longer than 63 requires a two byte repeat
+	for i := 0; i < 100; i++ {
+		appendValue(1)
+	}
+	appendValue(2)
+	appendValue(2)
+
+	// writes other than Append invalidate the cached tail
+	for _, r := range []*RLE{got, want} {
+		if err := r.InsertAt(n, 3); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	n++
+	appendValue(3)
+	for _, r := range []*RLE{got, want} {
+		if err := r.DeleteAt(n - 1); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	n--
+	appendValue(4)
+
+	if !reflect.DeepEqual(got.buffer, want.buffer) {
+		t.Fatalf("got %v, want %v", got.buffer, want.buffer)
+	}
+	if want, got := 104, got.RowCount(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// values needing more than 8 bytes as a var int must not overflow the rle buffer
+func TestRLE_LargeValues(t *testing.T) {
+	values := []int64{math.MaxInt64, math.MinInt64, 1 << 56, -1 << 56}
+
+	r := NewRLE(nil)
+	for i, v := range values {
+		if err := r.InsertAt(int64(i), v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	if got, want := readAllRLE(r.buffer), values; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	r = NewRLE(nil)
+	for _, v := range values {
+		if err := r.Append(v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	if got, want := readAllRLE(r.buffer), values; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRLE_Validate(t *testing.T) {
+	testCases := map[string]struct {
+		Buffer []byte
+		Rows   int64
+		OK     bool
+	}{
+		"valid":           {Buffer: []byte{0x06, 0x02}, Rows: 3, OK: true},
+		"null run":        {Buffer: []byte{0x00, 0x06}, Rows: 3, OK: true},
+		"empty":           {Rows: 0, OK: true},
+		"too few rows":    {Buffer: []byte{0x06, 0x02}, Rows: 4},
+		"too many rows":   {Buffer: []byte{0x06, 0x02}, Rows: 2},
+		"negative repeat": {Buffer: []byte{0x01, 0x02}, Rows: 0},
+		"truncated":       {Buffer: []byte{0x06}, Rows: 3},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			err := NewRLE(tc.Buffer).Validate(tc.Rows)
+			if got, want := err == nil, tc.OK; got != want {
+				t.Fatalf("got %v, want ok %v", err, want)
+			}
+		})
+	}
+}
+
+func TestRLE_Get(t *testing.T) {
+	r := NewRLE(nil)
+	for i, v := range []int64{1, 1, 2, 3, 3, 3} {
+		if err := r.InsertAt(int64(i), v); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+
+	for i, want := range []int64{1, 1, 2, 3, 3, 3} {
+		got, err := r.Get(int64(i))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if _, err := r.Get(6); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestRLE_SkipIndex(t *testing.T) {
+	var (
+		random = rand.New(rand.NewSource(1))
+		got    = NewRLE(nil)
+		want   = NewRLE(nil)
+		n      int64
+	)
+	got.EnableSkipIndex(4)
+
+	for i := 0; i < 2000; i++ {
+		switch op := random.Intn(10); {
+		case op < 6 || n == 0:
+			index, v := random.Int63n(n+1), random.Int63n(3)
+			for _, r := range []*RLE{got, want} {
+				if err := r.InsertAt(index, v); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+			}
+			n++
+
+		case op < 8:
+			index := random.Int63n(n)
+			for _, r := range []*RLE{got, want} {
+				if err := r.DeleteAt(index); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+			}
+			n--
+
+		default:
+			if err := got.Append(random.Int63n(3)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want.buffer = append([]byte(nil), got.buffer...)
+			n++
+		}
+
+		index := random.Int63n(n + 1)
+		if index < n {
+			g, err := got.Get(index)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			w, err := want.Get(index)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if g != w {
+				t.Fatalf("got %v, want %v", g, w)
+			}
+		}
+
+		gotValues, _ := got.Int64()
+		wantValues, _ := want.Int64()
+		if !reflect.DeepEqual(gotValues, wantValues) {
+			t.Fatalf("got %v, want %v", gotValues, wantValues)
+		}
+	}
+
+	t.Run("split", func(t *testing.T) {
+		values, _ := got.Int64()
+		index := int64(len(values) / 2)
+		left, right, err := got.SplitAt(index)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if left.skip.Interval() != 4 || right.skip.Interval() != 4 {
+			t.Fatalf("got %v and %v, want 4", left.skip.Interval(), right.skip.Interval())
+		}
+
+		l, _ := left.Int64()
+		r, _ := right.Int64()
+		if got := append(l, r...); !reflect.DeepEqual(got, values) {
+			t.Fatalf("got %v, want %v", got, values)
+		}
+	})
 }
 
 func TestRLE_Next(t *testing.T) {
@@ -498,6 +727,49 @@ func TestRLE_SplitAt(t *testing.T) {
 	})
 }
 
+func TestRLE_Concat(t *testing.T) {
+	testCases := map[string]struct {
+		Left, Right []int64
+		Runs        int
+	}{
+		"empty":      {Left: nil, Right: nil, Runs: 0},
+		"empty left": {Left: nil, Right: []int64{1, 1}, Runs: 1},
+		"join":       {Left: []int64{1, 2, 2}, Right: []int64{2, 3}, Runs: 3},
+		"no join":    {Left: []int64{1, 2}, Right: []int64{3, 3}, Runs: 3},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			left, right := NewRLE(nil), NewRLE(nil)
+			for _, v := range tc.Left {
+				_ = left.Append(v)
+			}
+			for _, v := range tc.Right {
+				_ = right.Append(v)
+			}
+
+			got, err := left.Concat(right)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			want := append(append([]int64(nil), tc.Left...), tc.Right...)
+			if values, _ := got.Int64(); !reflect.DeepEqual(values, want) {
+				t.Fatalf("got %v, want %v", values, want)
+			}
+
+			var runs int
+			for pos := 0; pos < len(got.buffer); runs++ {
+				block, _ := got.readAt(pos)
+				pos += block.Length
+			}
+			if runs != tc.Runs {
+				t.Fatalf("got %v, want %v", runs, tc.Runs)
+			}
+		})
+	}
+}
+
 func BenchmarkRLE_Next(t *testing.B) {
 	const n = 1e3
 
@@ -603,3 +875,109 @@ func TestRLE_Translate(t *testing.T) {
 		}
 	})
 }
+
+func TestRLE_Null(t *testing.T) {
+	t.Run("encoding", func(t *testing.T) {
+		r := NewRLE(nil)
+		for _, fn := range []func() error{r.AppendNull, r.AppendNull, func() error { return r.Append(0) }} {
+			if err := fn(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+		if want, got := []byte{0, 4, 2, 0}, r.Raw(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+
+		var token RLEToken
+		var err error
+		for i, want := range []bool{true, true, false} {
+			token, err = r.Next(token)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if got := token.Null; got != want {
+				t.Fatalf("got %v, want %v at %v", got, want, i)
+			}
+		}
+		if _, err := r.Next(token); !errors.Is(err, io.EOF) {
+			t.Fatalf("got %v; want %v", err, io.EOF)
+		}
+	})
+
+	testCases := map[string]int{
+		"plain":      0,
+		"skip index": 2,
+	}
+	for label, interval := range testCases {
+		t.Run(label, func(t *testing.T) {
+			var (
+				random = rand.New(rand.NewSource(1))
+				r      = NewRLE(nil)
+				values []int64
+				nulls  []bool
+			)
+			r.EnableSkipIndex(interval)
+			for i := 0; i < 1000; i++ {
+				if len(values) == 0 || random.Intn(3) > 0 {
+					index, v, null := random.Intn(len(values)+1), random.Int63n(3), random.Intn(3) == 0
+					var err error
+					if null {
+						v, err = 0, r.InsertNullAt(int64(index))
+					} else {
+						err = r.InsertAt(int64(index), v)
+					}
+					if err != nil {
+						t.Fatalf("got %v; want nil", err)
+					}
+					values = append(values[:index], append([]int64{v}, values[index:]...)...)
+					nulls = append(nulls[:index], append([]bool{null}, nulls[index:]...)...)
+				} else {
+					index := random.Intn(len(values))
+					if err := r.DeleteAt(int64(index)); err != nil {
+						t.Fatalf("got %v; want nil", err)
+					}
+					values = append(values[:index], values[index+1:]...)
+					nulls = append(nulls[:index], nulls[index+1:]...)
+				}
+
+				index := random.Intn(len(values) + 1)
+				if index == len(values) {
+					continue
+				}
+				null, err := r.IsNull(int64(index))
+				if err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				if want := nulls[index]; null != want {
+					t.Fatalf("got %v, want %v", null, want)
+				}
+			}
+
+			got, err := r.Int64()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(got, values) && len(got)+len(values) > 0 {
+				t.Fatalf("got %v, want %v", got, values)
+			}
+
+			left, right, err := r.SplitAt(int64(len(values) / 2))
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			joined, err := left.Concat(right)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			for i, want := range nulls {
+				got, err := joined.IsNull(int64(i))
+				if err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				if got != want {
+					t.Fatalf("got %v, want %v at %v", got, want, i)
+				}
+			}
+		})
+	}
+}
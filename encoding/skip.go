@@ -0,0 +1,100 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import "sort"
+
+// skipEntry records the byte position, Pos, of the run or value beginning at row, Row,
+// along with Sum, the sum of all values preceding it
+type skipEntry struct {
+	Row int64
+	Pos int
+	Sum int64
+}
+
+// skipIndex holds an entry every interval runs or values so seeks may begin part way
+// through a buffer.  The index is built on first use and adjusted in place as the buffer
+// is edited.  As edits spread entries apart, the index is discarded and rebuilt.
+type skipIndex struct {
+	interval int
+	entries  []skipEntry
+	built    bool
+	edits    int
+}
+
+func newSkipIndex(interval int) *skipIndex {
+	if interval <= 0 {
+		return nil
+	}
+	return &skipIndex{interval: interval}
+}
+
+// Interval returns the number of runs or values between entries; 0 if s is disabled
+func (s *skipIndex) Interval() int {
+	if s == nil {
+		return 0
+	}
+	return s.interval
+}
+
+// find returns the last entry at or before row, building the index with build if required
+func (s *skipIndex) find(row int64, build func(interval int) []skipEntry) skipEntry {
+	if s == nil || row <= 0 {
+		return skipEntry{}
+	}
+	if !s.built {
+		s.entries = build(s.interval)
+		s.built = true
+		s.edits = 0
+	}
+
+	k := sort.Search(len(s.entries), func(k int) bool { return s.entries[k].Row > row })
+	if k == 0 {
+		return skipEntry{}
+	}
+	return s.entries[k-1]
+}
+
+// adjust accounts for an edit to the run or value at pos that moved all following bytes by
+// bytes, following rows by rows, and the sum of values preceding them by sum.  size is the
+// length of the buffer after the edit.
+func (s *skipIndex) adjust(pos, bytes int, rows, sum int64, size int) {
+	if s == nil || !s.built {
+		return
+	}
+
+	s.edits++
+	if s.edits > s.interval*(len(s.entries)+1) {
+		s.built = false // entries have likely spread apart; rebuild on next use
+		s.entries = s.entries[:0]
+		return
+	}
+
+	k := sort.Search(len(s.entries), func(k int) bool { return s.entries[k].Pos > pos })
+	entries := s.entries[:k]
+	for _, entry := range s.entries[k:] {
+		entry.Pos += bytes
+		entry.Row += rows
+		entry.Sum += sum
+		if n := len(entries); n > 0 && entries[n-1].Pos >= entry.Pos {
+			continue // the run or value preceding entry was removed
+		}
+		entries = append(entries, entry)
+	}
+	for n := len(entries); n > 0 && entries[n-1].Pos >= size; n-- {
+		entries = entries[:n-1]
+	}
+	s.entries = entries
+}
@@ -30,7 +30,7 @@ func putUint32(v uint32) (buf [4]byte) {
 	return
 }
 
-func putVarInt(v int64) (buf [8]byte, n int) {
+func putVarInt(v int64) (buf [binary.MaxVarintLen64]byte, n int) {
 	n = binary.PutVarint(buf[:], v)
 	return
 }
@@ -93,3 +93,35 @@ func max(a, b int) int {
 	}
 	return b
 }
+
+// appendLeb appends v encoded as signed LEB128
+func appendLeb(buffer []byte, v int64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(buffer, b)
+		}
+		buffer = append(buffer, b|0x80)
+	}
+}
+
+// readLeb decodes a signed LEB128 value from buffer and returns the number of bytes read.
+// n is 0 if buffer is too short and negative if the value overflows 64 bits.
+func readLeb(buffer []byte) (v int64, n int) {
+	var shift uint
+	for i, b := range buffer {
+		if i == binary.MaxVarintLen64 {
+			return 0, -(i + 1) // overflow
+		}
+		v |= int64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			if shift < 64 && b&0x40 != 0 {
+				v |= -1 << shift // sign extend
+			}
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
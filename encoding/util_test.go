@@ -18,6 +18,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"github.com/willf/bloom"
+	"math"
 	"reflect"
 	"testing"
 )
@@ -196,3 +197,38 @@ func TestBloomFilter(t *testing.T) {
 		t.Fatalf("got %v; want %v", len(got), want)
 	}
 }
+
+func Test_leb(t *testing.T) {
+	testCases := map[string]struct {
+		value int64
+		want  []byte
+	}{
+		"zero":     {value: 0, want: []byte{0x00}},
+		"positive": {value: 2, want: []byte{0x02}},
+		"negative": {value: -2, want: []byte{0x7e}},
+		"sign bit": {value: 64, want: []byte{0xc0, 0x00}},
+		"two byte": {value: -129, want: []byte{0xff, 0x7e}},
+		"max":      {value: math.MaxInt64, want: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}},
+		"min":      {value: math.MinInt64, want: []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x7f}},
+	}
+	for label, tt := range testCases {
+		t.Run(label, func(t *testing.T) {
+			got := appendLeb(nil, tt.value)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+
+			v, n := readLeb(got)
+			if n != len(got) {
+				t.Fatalf("got %v, want %v", n, len(got))
+			}
+			if v != tt.value {
+				t.Fatalf("got %v, want %v", v, tt.value)
+			}
+		})
+	}
+
+	if _, n := readLeb([]byte{0x80}); n != 0 {
+		t.Fatalf("got %v, want 0", n)
+	}
+}
@@ -17,6 +17,9 @@ package encoding
 import (
 	"encoding/binary"
 	"fmt"
+	"io"
+	"math"
+	"time"
 )
 
 type RawType uint8
@@ -26,6 +29,11 @@ const (
 	RawTypeUnknown   RawType = 0
 	RawTypeVarInt    RawType = 1
 	RawTypeByteArray RawType = 2
+	RawTypeFloat64   RawType = 3 // little endian IEEE 754
+	RawTypeBool      RawType = 4 // single byte, 0 or 1
+	RawTypeNull      RawType = 5 // single zero byte so that nulls occupy a row
+	RawTypeTimestamp RawType = 6 // var int milliseconds since the unix epoch
+	RawTypeCounter   RawType = 7 // var int
 )
 
 const (
@@ -38,17 +46,75 @@ const (
 type Value struct {
 	length  int
 	Int     int64
+	Float   float64
 	Bytes   []byte
 	RawType RawType
+	Type    ValueType // Type of the value within a ValueColumn; see ValueType()
 }
 
-func (v Value) Append(buffer []byte) ([]byte, error) {
+// ValueType returns the type of v within a ValueColumn.  Values without an explicit Type
+// are typed by their raw type; var ints as leb and byte arrays as bytes.
+func (v Value) ValueType() ValueType {
+	if v.Type != ValueTypeNull {
+		return v.Type
+	}
+
 	switch v.RawType {
 	case RawTypeVarInt:
+		return ValueTypeLeb
+	case RawTypeByteArray:
+		return ValueTypeBytes
+	case RawTypeFloat64:
+		return ValueTypeFloat64
+	case RawTypeBool:
+		if v.Int != 0 {
+			return ValueTypeTrue
+		}
+		return ValueTypeFalse
+	case RawTypeTimestamp:
+		return ValueTypeTimestamp
+	case RawTypeCounter:
+		return ValueTypeCounter
+	default:
+		return ValueTypeNull
+	}
+}
+
+// Bool returns true if v holds the boolean true
+func (v Value) Bool() bool {
+	return v.ValueType() == ValueTypeTrue
+}
+
+// Float64 returns the float held by v
+func (v Value) Float64() float64 {
+	return v.Float
+}
+
+// IsNull returns true if v holds no value
+func (v Value) IsNull() bool {
+	return v.ValueType() == ValueTypeNull
+}
+
+// Timestamp returns the time held by v to millisecond precision
+func (v Value) Timestamp() time.Time {
+	return time.Unix(v.Int/1000, (v.Int%1000)*int64(time.Millisecond))
+}
+
+func (v Value) Append(buffer []byte) ([]byte, error) {
+	switch v.RawType {
+	case RawTypeVarInt, RawTypeTimestamp, RawTypeCounter:
 		buf, n := putVarInt(v.Int)
 		buffer = append(buffer, buf[:n]...)
 		return buffer, nil
 
+	case RawTypeFloat64:
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v.Float))
+		return append(buffer, buf[:]...), nil
+
+	case RawTypeBool, RawTypeNull:
+		return append(buffer, byte(v.Int)), nil
+
 	case RawTypeByteArray:
 		length := len(v.Bytes)
 		buf, n := putVarInt(int64(length))
@@ -63,10 +129,16 @@ func (v Value) Append(buffer []byte) ([]byte, error) {
 
 func (v Value) Copy(target []byte) {
 	switch v.RawType {
-	case RawTypeVarInt:
+	case RawTypeVarInt, RawTypeTimestamp, RawTypeCounter:
 		buf, n := putVarInt(v.Int)
 		copy(target, buf[0:n])
 
+	case RawTypeFloat64:
+		binary.LittleEndian.PutUint64(target, math.Float64bits(v.Float))
+
+	case RawTypeBool, RawTypeNull:
+		target[0] = byte(v.Int)
+
 	case RawTypeByteArray:
 		length := len(v.Bytes)
 		buf, n := putVarInt(int64(length))
@@ -82,10 +154,16 @@ func (v Value) Length() int {
 	}
 
 	switch v.RawType {
-	case RawTypeVarInt:
+	case RawTypeVarInt, RawTypeTimestamp, RawTypeCounter:
 		_, length := putVarInt(v.Int)
 		return length
 
+	case RawTypeFloat64:
+		return 8
+
+	case RawTypeBool, RawTypeNull:
+		return 1
+
 	case RawTypeByteArray:
 		n := len(v.Bytes)
 		_, length := putVarInt(int64(n))
@@ -98,16 +176,40 @@ func (v Value) Length() int {
 
 func ReadValue(rawType RawType, buffer []byte) (Value, error) {
 	switch rawType {
-	case RawTypeVarInt:
+	case RawTypeVarInt, RawTypeTimestamp, RawTypeCounter:
 		v, length := binary.Varint(buffer)
+		if length <= 0 {
+			return Value{}, fmt.Errorf("unable to read var int: %w", io.ErrUnexpectedEOF)
+		}
 		return Value{
 			length:  length,
 			Int:     v,
 			RawType: rawType,
 		}, nil
 
+	case RawTypeFloat64:
+		if len(buffer) < 8 {
+			return Value{}, fmt.Errorf("unable to read float64: %w", io.ErrUnexpectedEOF)
+		}
+		return Float64Value(math.Float64frombits(binary.LittleEndian.Uint64(buffer))), nil
+
+	case RawTypeBool:
+		if len(buffer) < 1 || buffer[0] > 1 {
+			return Value{}, fmt.Errorf("unable to read bool: %w", io.ErrUnexpectedEOF)
+		}
+		return BoolValue(buffer[0] == 1), nil
+
+	case RawTypeNull:
+		if len(buffer) < 1 || buffer[0] != 0 {
+			return Value{}, fmt.Errorf("unable to read null: %w", io.ErrUnexpectedEOF)
+		}
+		return NullValue(), nil
+
 	case RawTypeByteArray:
 		v, vl := binary.Varint(buffer)
+		if vl <= 0 || v < 0 || int64(len(buffer)-vl) < v {
+			return Value{}, fmt.Errorf("unable to read byte array: %w", io.ErrUnexpectedEOF)
+		}
 
 		length := vl + int(v)
 		return Value{
@@ -140,6 +242,48 @@ func Int64Value(v int64) Value {
 	}
 }
 
+// Float64Value encodes to 8 bytes, little endian
+func Float64Value(f float64) Value {
+	return Value{
+		length:  8,
+		Float:   f,
+		RawType: RawTypeFloat64,
+	}
+}
+
+// BoolValue encodes to a single byte, 0 or 1
+func BoolValue(b bool) Value {
+	if b {
+		return Value{length: 1, Int: 1, RawType: RawTypeBool}
+	}
+	return Value{length: 1, RawType: RawTypeBool}
+}
+
+// NullValue encodes to a single zero byte.  Unlike the zero Value, which has no raw type,
+// NullValue occupies a row when encoded.
+func NullValue() Value {
+	return Value{
+		length:  1,
+		RawType: RawTypeNull,
+	}
+}
+
+// TimestampValue encodes to var int milliseconds since the unix epoch
+func TimestampValue(t time.Time) Value {
+	return Value{
+		Int:     t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond),
+		RawType: RawTypeTimestamp,
+	}
+}
+
+// CounterValue encodes to var int value
+func CounterValue(v int64) Value {
+	return Value{
+		Int:     v,
+		RawType: RawTypeCounter,
+	}
+}
+
 // RuneValue encodes var int value
 func RuneValue(r rune) Value {
 	return Value{
@@ -167,9 +311,12 @@ func PropertyValue(key int64, value []byte) Value {
 	return ByteSliceValue(data)
 }
 
-// StringValue encodes to a var int length followed by a byte array
+// StringValue encodes to a var int length followed by a byte array.  The value is typed
+// as utf8 so that a ValueColumn distinguishes it from arbitrary bytes.
 func StringValue(s string) Value {
-	return ByteSliceValue([]byte(s))
+	v := ByteSliceValue([]byte(s))
+	v.Type = ValueTypeUTF8
+	return v
 }
 
 func DecodePropertyValue(buffer []byte) (int64, []byte, error) {
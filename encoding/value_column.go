@@ -0,0 +1,310 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf8"
+)
+
+// ValueType identifies the type of a value held by a ValueColumn.  Codes match the
+// value metadata column of the automerge column specification.
+type ValueType uint8
+
+const (
+	ValueTypeNull      ValueType = 0
+	ValueTypeFalse     ValueType = 1
+	ValueTypeTrue      ValueType = 2
+	ValueTypeUleb      ValueType = 3 // unsigned LEB128
+	ValueTypeLeb       ValueType = 4 // signed LEB128
+	ValueTypeFloat64   ValueType = 5 // little endian IEEE 754
+	ValueTypeUTF8      ValueType = 6
+	ValueTypeBytes     ValueType = 7
+	ValueTypeCounter   ValueType = 8 // signed LEB128
+	ValueTypeTimestamp ValueType = 9 // signed LEB128
+)
+
+// valueTypeBits holds the number of bits of a metadata entry used by the value type; the
+// remaining bits hold the length of the raw value
+const valueTypeBits = 4
+
+// ValueColumn holds values of mixed types in a pair of columns; an rle encoded metadata
+// column of (length << 4 | type) per value, and a raw column of the value bytes.
+type ValueColumn struct {
+	meta *RLE
+	raw  []byte
+}
+
+type ValueColumnToken struct {
+	meta  RLEToken
+	pos   int // byte position in raw following the value
+	Index int
+	Value Value
+}
+
+func NewValueColumn(meta, raw []byte) *ValueColumn {
+	return &ValueColumn{
+		meta: NewRLE(meta),
+		raw:  raw,
+	}
+}
+
+// encodeValue returns the raw bytes of v along with its metadata entry
+func encodeValue(v Value) ([]byte, int64, error) {
+	var data []byte
+	switch t := v.ValueType(); t {
+	case ValueTypeNull, ValueTypeFalse, ValueTypeTrue:
+		// type alone
+
+	case ValueTypeUleb:
+		if v.Int < 0 {
+			return nil, 0, fmt.Errorf("unable to encode uleb value, %v: negative value", v.Int)
+		}
+		var buf [binary.MaxVarintLen64]byte
+		n := binary.PutUvarint(buf[:], uint64(v.Int))
+		data = buf[:n]
+
+	case ValueTypeLeb, ValueTypeCounter, ValueTypeTimestamp:
+		data = appendLeb(nil, v.Int)
+
+	case ValueTypeFloat64:
+		data = make([]byte, 8)
+		binary.LittleEndian.PutUint64(data, math.Float64bits(v.Float))
+
+	case ValueTypeUTF8:
+		if !utf8.Valid(v.Bytes) {
+			return nil, 0, fmt.Errorf("unable to encode utf8 value: invalid utf8")
+		}
+		data = v.Bytes
+
+	case ValueTypeBytes:
+		data = v.Bytes
+
+	default:
+		return nil, 0, fmt.Errorf("unable to encode value: unknown value type, %v", t)
+	}
+
+	meta := int64(len(data))<<valueTypeBits | int64(v.ValueType())
+	return data, meta, nil
+}
+
+// decodeValue returns the value described by the metadata entry, meta, from data
+func decodeValue(meta int64, data []byte) (Value, error) {
+	var (
+		t      = ValueType(meta & (1<<valueTypeBits - 1))
+		length = meta >> valueTypeBits
+	)
+	if length < 0 || int64(len(data)) < length {
+		return Value{}, fmt.Errorf("unable to decode value: %w", io.ErrUnexpectedEOF)
+	}
+	data = data[:length]
+
+	switch t {
+	case ValueTypeNull:
+		return NullValue(), nil
+
+	case ValueTypeFalse, ValueTypeTrue:
+		return BoolValue(t == ValueTypeTrue), nil
+
+	case ValueTypeUleb:
+		v, n := binary.Uvarint(data)
+		if n <= 0 || n != len(data) || v > math.MaxInt64 {
+			return Value{}, fmt.Errorf("unable to decode uleb value: %w", io.ErrUnexpectedEOF)
+		}
+		return Value{Int: int64(v), RawType: RawTypeVarInt, Type: t}, nil
+
+	case ValueTypeLeb, ValueTypeCounter, ValueTypeTimestamp:
+		v, n := readLeb(data)
+		if n <= 0 || n != len(data) {
+			return Value{}, fmt.Errorf("unable to decode leb value: %w", io.ErrUnexpectedEOF)
+		}
+		switch t {
+		case ValueTypeCounter:
+			return CounterValue(v), nil
+		case ValueTypeTimestamp:
+			return Value{Int: v, RawType: RawTypeTimestamp}, nil
+		default:
+			return Int64Value(v), nil
+		}
+
+	case ValueTypeFloat64:
+		if len(data) != 8 {
+			return Value{}, fmt.Errorf("unable to decode float64 value: %w", io.ErrUnexpectedEOF)
+		}
+		return Float64Value(math.Float64frombits(binary.LittleEndian.Uint64(data))), nil
+
+	case ValueTypeUTF8:
+		v := ByteSliceValue(data)
+		v.Type = t
+		return v, nil
+
+	case ValueTypeBytes:
+		return ByteSliceValue(data), nil
+
+	default:
+		return Value{}, fmt.Errorf("unable to decode value: unknown value type, %v", t)
+	}
+}
+
+// offset returns the byte position in raw of the value at index
+func (c *ValueColumn) offset(index int64) (int, error) {
+	var pos int
+	var token RLEToken
+	var err error
+	for i := int64(0); i < index; i++ {
+		token, err = c.meta.Next(token)
+		if err != nil {
+			if err == io.EOF {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		pos += int(token.Value >> valueTypeBits)
+	}
+	return pos, nil
+}
+
+func (c *ValueColumn) Get(index int64) (Value, error) {
+	if index < 0 {
+		return Value{}, fmt.Errorf("unable to get value @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+
+	pos, err := c.offset(index)
+	if err != nil {
+		return Value{}, fmt.Errorf("unable to get value @%v: %w", index, err)
+	}
+	meta, err := c.meta.Get(index)
+	if err != nil {
+		return Value{}, fmt.Errorf("unable to get value @%v: %w", index, err)
+	}
+	return decodeValue(meta, c.raw[pos:])
+}
+
+func (c *ValueColumn) InsertAt(index int64, v Value) error {
+	if index < 0 {
+		return fmt.Errorf("unable to insert value @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+
+	data, meta, err := encodeValue(v)
+	if err != nil {
+		return err
+	}
+	pos, err := c.offset(index)
+	if err != nil {
+		return fmt.Errorf("unable to insert value @%v: %w", index, err)
+	}
+	if err := c.meta.InsertAt(index, meta); err != nil {
+		return fmt.Errorf("unable to insert value @%v: %w", index, err)
+	}
+	c.raw = insertAt(c.raw, pos, data...)
+	return nil
+}
+
+// Append adds v after the last value
+func (c *ValueColumn) Append(v Value) error {
+	data, meta, err := encodeValue(v)
+	if err != nil {
+		return err
+	}
+	if err := c.meta.Append(meta); err != nil {
+		return fmt.Errorf("unable to append value: %w", err)
+	}
+	c.raw = append(c.raw, data...)
+	return nil
+}
+
+// DeleteAt removes the value at index
+func (c *ValueColumn) DeleteAt(index int64) error {
+	if index < 0 {
+		return fmt.Errorf("unable to delete value @%v: %w", index, io.ErrUnexpectedEOF)
+	}
+
+	pos, err := c.offset(index)
+	if err != nil {
+		return fmt.Errorf("unable to delete value @%v: %w", index, err)
+	}
+	meta, err := c.meta.Get(index)
+	if err != nil {
+		return fmt.Errorf("unable to delete value @%v: %w", index, err)
+	}
+	if err := c.meta.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete value @%v: %w", index, err)
+	}
+	c.raw = unshift(c.raw, pos, int(meta>>valueTypeBits))
+	return nil
+}
+
+func (c *ValueColumn) Next(token ValueColumnToken) (ValueColumnToken, error) {
+	metaToken, err := c.meta.Next(token.meta)
+	if err != nil {
+		return ValueColumnToken{}, err
+	}
+
+	v, err := decodeValue(metaToken.Value, c.raw[token.pos:])
+	if err != nil {
+		return ValueColumnToken{}, err
+	}
+
+	return ValueColumnToken{
+		meta:  metaToken,
+		pos:   token.pos + int(metaToken.Value>>valueTypeBits),
+		Index: metaToken.Index,
+		Value: v,
+	}, nil
+}
+
+// Raw returns the underlying encoded bytes of the metadata and raw columns
+func (c *ValueColumn) Raw() (meta, raw []byte) {
+	return c.meta.Raw(), c.raw
+}
+
+func (c *ValueColumn) RowCount() int {
+	return c.meta.RowCount()
+}
+
+func (c *ValueColumn) Size() int {
+	return c.meta.Size() + len(c.raw)
+}
+
+func (c *ValueColumn) SplitAt(index int64) (left, right *ValueColumn, err error) {
+	pos, err := c.offset(index)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to split values at index, %v: %w", index, err)
+	}
+	l, r, err := c.meta.SplitAt(index)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to split values at index, %v: %w", index, err)
+	}
+
+	left = &ValueColumn{meta: l, raw: append([]byte(nil), c.raw[:pos]...)}
+	right = &ValueColumn{meta: r, raw: append([]byte(nil), c.raw[pos:]...)}
+	return left, right, nil
+}
+
+// Concat returns a ValueColumn holding the values of c followed by the values of that
+func (c *ValueColumn) Concat(that *ValueColumn) (*ValueColumn, error) {
+	meta, err := c.meta.Concat(that.meta)
+	if err != nil {
+		return nil, fmt.Errorf("unable to concat values: %w", err)
+	}
+
+	raw := make([]byte, 0, len(c.raw)+len(that.raw))
+	raw = append(raw, c.raw...)
+	raw = append(raw, that.raw...)
+	return &ValueColumn{meta: meta, raw: raw}, nil
+}
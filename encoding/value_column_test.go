@@ -0,0 +1,181 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package encoding
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestValueColumn_Append(t *testing.T) {
+	testCases := map[string]struct {
+		Value Value
+		Meta  int64
+		Raw   []byte
+	}{
+		"null":      {Value: NullValue(), Meta: 0x00, Raw: nil},
+		"false":     {Value: BoolValue(false), Meta: 0x01, Raw: nil},
+		"true":      {Value: BoolValue(true), Meta: 0x02, Raw: nil},
+		"uleb":      {Value: Value{Int: 300, RawType: RawTypeVarInt, Type: ValueTypeUleb}, Meta: 0x23, Raw: []byte{0xac, 0x02}},
+		"leb":       {Value: Int64Value(-2), Meta: 0x14, Raw: []byte{0x7e}},
+		"float64":   {Value: Float64Value(1.5), Meta: 0x85, Raw: []byte{0, 0, 0, 0, 0, 0, 0xf8, 0x3f}},
+		"utf8":      {Value: StringValue("hi"), Meta: 0x26, Raw: []byte("hi")},
+		"bytes":     {Value: ByteSliceValue([]byte{1, 2, 3}), Meta: 0x37, Raw: []byte{1, 2, 3}},
+		"counter":   {Value: CounterValue(1), Meta: 0x18, Raw: []byte{0x01}},
+		"timestamp": {Value: Value{Int: 1, RawType: RawTypeTimestamp}, Meta: 0x19, Raw: []byte{0x01}},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			c := NewValueColumn(nil, nil)
+			if err := c.Append(tc.Value); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			meta, raw := c.Raw()
+			if want, got := []byte{2, byte(tc.Meta << 1)}, meta; tc.Meta < 64 && !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if got := raw; !reflect.DeepEqual(got, tc.Raw) && len(got)+len(tc.Raw) > 0 {
+				t.Fatalf("got %v, want %v", got, tc.Raw)
+			}
+
+			got, err := c.Get(0)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if !reflect.DeepEqual(got, tc.Value) {
+				t.Fatalf("got %#v, want %#v", got, tc.Value)
+			}
+		})
+	}
+
+	t.Run("raw type", func(t *testing.T) {
+		c := NewValueColumn(nil, nil)
+		for _, v := range []Value{Int64Value(-3), StringValue("abc"), ByteSliceValue([]byte("abc"))} {
+			if err := c.Append(v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+
+		for i, want := range []ValueType{ValueTypeLeb, ValueTypeUTF8, ValueTypeBytes} {
+			got, err := c.Get(int64(i))
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if got := got.ValueType(); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		c := NewValueColumn(nil, nil)
+		if err := c.Append(Value{Int: -1, Type: ValueTypeUleb}); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+		if err := c.Append(Value{Bytes: []byte{0xff}, Type: ValueTypeUTF8}); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+		if err := c.Append(Value{Type: 15}); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+	})
+}
+
+func TestValueColumn_InsertAt(t *testing.T) {
+	values := []Value{
+		NullValue(),
+		BoolValue(true),
+		Int64Value(7),
+		Float64Value(-0.25),
+		StringValue("abc"),
+		{Int: 1e12, RawType: RawTypeTimestamp},
+	}
+
+	var (
+		random = rand.New(rand.NewSource(1))
+		c      = NewValueColumn(nil, nil)
+		want   []Value
+	)
+	for i := 0; i < 500; i++ {
+		if len(want) == 0 || random.Intn(3) > 0 {
+			index, v := random.Intn(len(want)+1), values[random.Intn(len(values))]
+			if err := c.InsertAt(int64(index), v); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:index], append([]Value{v}, want[index:]...)...)
+		} else {
+			index := random.Intn(len(want))
+			if err := c.DeleteAt(int64(index)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:index], want[index+1:]...)
+		}
+
+		if got := readAllValueColumn(t, c); !reflect.DeepEqual(got, want) && len(got)+len(want) > 0 {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if err := c.InsertAt(int64(len(want)+1), NullValue()); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+	if err := c.DeleteAt(int64(len(want))); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+
+	for index := 0; index <= len(want); index += 11 {
+		left, right, err := c.SplitAt(int64(index))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got := readAllValueColumn(t, left); !reflect.DeepEqual(got, want[:index]) && len(got)+index > 0 {
+			t.Fatalf("got %v, want %v", got, want[:index])
+		}
+		if got := readAllValueColumn(t, right); !reflect.DeepEqual(got, want[index:]) && len(got)+len(want)-index > 0 {
+			t.Fatalf("got %v, want %v", got, want[index:])
+		}
+
+		joined, err := left.Concat(right)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got := readAllValueColumn(t, joined); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func readAllValueColumn(t *testing.T, c *ValueColumn) []Value {
+	var got []Value
+	var token ValueColumnToken
+	var err error
+	for {
+		token, err = c.Next(token)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		got = append(got, token.Value)
+	}
+	return got
+}
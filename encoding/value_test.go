@@ -15,7 +15,10 @@
 package encoding
 
 import (
+	"math"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestReadValue(t *testing.T) {
@@ -90,6 +93,98 @@ func TestReadValue(t *testing.T) {
 	})
 }
 
+func TestReadValue_Types(t *testing.T) {
+	now := time.Unix(1600000000, 123000000)
+	testCases := map[string]struct {
+		Value Value
+		Type  ValueType
+	}{
+		"float64":     {Value: Float64Value(math.Pi), Type: ValueTypeFloat64},
+		"true":        {Value: BoolValue(true), Type: ValueTypeTrue},
+		"false":       {Value: BoolValue(false), Type: ValueTypeFalse},
+		"null":        {Value: NullValue(), Type: ValueTypeNull},
+		"timestamp":   {Value: TimestampValue(now), Type: ValueTypeTimestamp},
+		"counter":     {Value: CounterValue(-3), Type: ValueTypeCounter},
+		"large int64": {Value: Int64Value(math.MinInt64), Type: ValueTypeLeb},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			data, err := tc.Value.Append(nil)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if want, got := tc.Value.Length(), len(data); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+
+			copied := make([]byte, tc.Value.Length())
+			tc.Value.Copy(copied)
+			if !reflect.DeepEqual(copied, data) {
+				t.Fatalf("got %v, want %v", copied, data)
+			}
+
+			got, err := ReadValue(tc.Value.RawType, data)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if got.Length() != len(data) {
+				t.Fatalf("got %v, want %v", got.Length(), len(data))
+			}
+			if got.Int != tc.Value.Int || got.Float != tc.Value.Float || got.RawType != tc.Value.RawType {
+				t.Fatalf("got %#v, want %#v", got, tc.Value)
+			}
+			if got := got.ValueType(); got != tc.Type {
+				t.Fatalf("got %v, want %v", got, tc.Type)
+			}
+		})
+	}
+
+	t.Run("accessors", func(t *testing.T) {
+		if got, want := Float64Value(1.5).Float64(), 1.5; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if got, want := BoolValue(true).Bool(), true; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if got, want := BoolValue(false).Bool(), false; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if got, want := NullValue().IsNull(), true; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if got, want := Int64Value(0).IsNull(), false; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if got, want := TimestampValue(now).Timestamp(), now; !got.Equal(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for _, want := range []time.Time{
+			time.Date(1500, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2500, 1, 1, 0, 0, 0, 123e6, time.UTC),
+		} {
+			if got := TimestampValue(want).Timestamp(); !got.Equal(want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+		if got, want := CounterValue(4).Int, int64(4); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		if _, err := ReadValue(RawTypeFloat64, []byte{1, 2, 3}); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+		if _, err := ReadValue(RawTypeBool, []byte{2}); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+		if _, err := ReadValue(RawTypeNull, nil); err == nil {
+			t.Fatalf("got nil; want err")
+		}
+	})
+}
+
 func TestPropertyValue(t *testing.T) {
 	k, v := int64(123), "abc"
 	buffer := PropertyValue(k, []byte(v))
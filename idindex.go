@@ -0,0 +1,183 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+)
+
+// idRange holds a contiguous run of counters for a single actor, all of which are
+// stored in the same page.  Pages are identified by their leaf in the page tree rather
+// than by position so that splitting or merging a page touches only its own ranges.
+type idRange struct {
+	From, To int64
+	Page     *node
+}
+
+// idIndex is an exact index from id to the page that holds it.  Ids are grouped by actor
+// into sorted, non-overlapping ranges of counters.  As text is typically entered in runs,
+// a single range will often cover an entire page.
+type idIndex struct {
+	actors map[string][]idRange
+}
+
+func newIDIndex() *idIndex {
+	return &idIndex{actors: map[string][]idRange{}}
+}
+
+// Add records that id is held by the page whose leaf is page
+func (x *idIndex) Add(id ID, page *node) {
+	var (
+		key    = string(id.Actor)
+		ranges = x.actors[key]
+		c      = id.Counter
+		i      = sort.Search(len(ranges), func(i int) bool { return ranges[i].From > c })
+	)
+
+	if i > 0 && ranges[i-1].To >= c {
+		return // already indexed
+	}
+
+	var (
+		joinPrev = i > 0 && ranges[i-1].To+1 == c && ranges[i-1].Page == page
+		joinNext = i < len(ranges) && ranges[i].From-1 == c && ranges[i].Page == page
+	)
+	switch {
+	case joinPrev && joinNext:
+		ranges[i-1].To = ranges[i].To
+		ranges = append(ranges[:i], ranges[i+1:]...)
+	case joinPrev:
+		ranges[i-1].To = c
+	case joinNext:
+		ranges[i].From = c
+	default:
+		ranges = append(ranges, idRange{})
+		copy(ranges[i+1:], ranges[i:])
+		ranges[i] = idRange{From: c, To: c, Page: page}
+	}
+	x.actors[key] = ranges
+}
+
+// Find returns the leaf of the page holding id
+func (x *idIndex) Find(id ID) (*node, bool) {
+	var (
+		ranges = x.actors[string(id.Actor)]
+		c      = id.Counter
+		i      = sort.Search(len(ranges), func(i int) bool { return ranges[i].From > c })
+	)
+	if i == 0 || ranges[i-1].To < c {
+		return nil, false
+	}
+	return ranges[i-1].Page, true
+}
+
+// Split updates the index after a page has been split in two.  The left half retains the
+// leaf of the original page so only the ids moved to the right half, whose leaf is page,
+// are reassigned.
+func (x *idIndex) Split(right *Page, page *node) error {
+	return x.assignPage(right, page)
+}
+
+// Merge updates the index after the page, right, has been merged into the page whose leaf
+// is page
+func (x *idIndex) Merge(right *Page, page *node) error {
+	return x.assignPage(right, page)
+}
+
+// assignPage assigns every id held by p to page.  Ids are reassigned in runs of
+// consecutive counters of the same actor.
+func (x *idIndex) assignPage(p *Page, page *node) error {
+	var (
+		token     IDToken
+		err       error
+		actor     []byte
+		from, to  int64
+		available bool
+	)
+	for {
+		token, err = p.NextID(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		if available && token.Counter == to+1 && bytes.Equal(token.Actor, actor) {
+			to = token.Counter
+			continue
+		}
+		if available {
+			x.assign(string(actor), from, to, page)
+		}
+		actor = append(actor[:0], token.Actor...)
+		from, to, available = token.Counter, token.Counter, true
+	}
+	if available {
+		x.assign(string(actor), from, to, page)
+	}
+	return nil
+}
+
+// assign records that the counters, from through to, of the actor identified by key are
+// held by page
+func (x *idIndex) assign(key string, from, to int64, page *node) {
+	var (
+		ranges = x.actors[key]
+		i      = sort.Search(len(ranges), func(i int) bool { return ranges[i].To >= from })
+		j      = sort.Search(len(ranges), func(j int) bool { return ranges[j].From > to })
+	)
+
+	// replace the ranges overlapping from..to, retaining the parts either side
+	replace := make([]idRange, 0, 3)
+	if i < j && ranges[i].From < from {
+		replace = append(replace, idRange{From: ranges[i].From, To: from - 1, Page: ranges[i].Page})
+	}
+	k := i + len(replace)
+	replace = append(replace, idRange{From: from, To: to, Page: page})
+	if i < j && ranges[j-1].To > to {
+		replace = append(replace, idRange{From: to + 1, To: ranges[j-1].To, Page: ranges[j-1].Page})
+	}
+	ranges = append(ranges[:i], append(replace, ranges[j:]...)...)
+
+	// join with neighbours held by the same page
+	if k+1 < len(ranges) && ranges[k+1].From == to+1 && ranges[k+1].Page == page {
+		ranges[k].To = ranges[k+1].To
+		ranges = append(ranges[:k+1], ranges[k+2:]...)
+	}
+	if k > 0 && ranges[k-1].To+1 == from && ranges[k-1].Page == page {
+		ranges[k-1].To = ranges[k].To
+		ranges = append(ranges[:k], ranges[k+1:]...)
+	}
+	x.actors[key] = ranges
+}
+
+func (x *idIndex) addPage(page *node, p *Page) error {
+	var token IDToken
+	var err error
+	for {
+		token, err = p.NextID(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		x.Add(NewID(token.Counter, token.Actor), page)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+func TestIDIndex_Add(t *testing.T) {
+	var (
+		actor  = []byte("me")
+		index  = newIDIndex()
+		p0, p1 = newLeaf(0, 0), newLeaf(0, 0)
+	)
+	for _, c := range []int64{1, 2, 3, 7, 5, 6, 4} {
+		index.Add(NewID(c, actor), p0)
+	}
+	index.Add(NewID(8, actor), p1)
+	index.Add(NewID(10, actor), p1)
+
+	want := []idRange{
+		{From: 1, To: 7, Page: p0},
+		{From: 8, To: 8, Page: p1},
+		{From: 10, To: 10, Page: p1},
+	}
+	if got := index.actors[string(actor)]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for c, want := range map[int64]*node{1: p0, 4: p0, 7: p0, 8: p1, 10: p1} {
+		got, ok := index.Find(NewID(c, actor))
+		if !ok || got != want {
+			t.Fatalf("got %p, %v; want %p, true", got, ok, want)
+		}
+	}
+	for _, id := range []ID{NewID(0, actor), NewID(9, actor), NewID(11, actor), NewID(1, []byte("you"))} {
+		if _, ok := index.Find(id); ok {
+			t.Fatalf("got true; want false for %v", id)
+		}
+	}
+}
+
+func TestIDIndex_Assign(t *testing.T) {
+	var (
+		actor  = []byte("me")
+		key    = string(actor)
+		a, b   = newLeaf(0, 0), newLeaf(0, 0)
+		c      = newLeaf(0, 0)
+		verify = func(t *testing.T, index *idIndex, want []idRange) {
+			if got := index.actors[key]; !reflect.DeepEqual(got, want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+	)
+
+	index := newIDIndex()
+	for i := int64(1); i <= 7; i++ {
+		index.Add(NewID(i, actor), a)
+	}
+	index.Add(NewID(9, actor), c)
+
+	index.assign(key, 3, 4, b)
+	verify(t, index, []idRange{{From: 1, To: 2, Page: a}, {From: 3, To: 4, Page: b}, {From: 5, To: 7, Page: a}, {From: 9, To: 9, Page: c}})
+
+	index.assign(key, 5, 7, b)
+	verify(t, index, []idRange{{From: 1, To: 2, Page: a}, {From: 3, To: 7, Page: b}, {From: 9, To: 9, Page: c}})
+
+	index.assign(key, 1, 2, b)
+	verify(t, index, []idRange{{From: 1, To: 7, Page: b}, {From: 9, To: 9, Page: c}})
+
+	index.assign(key, 9, 9, b)
+	verify(t, index, []idRange{{From: 1, To: 7, Page: b}, {From: 9, To: 9, Page: b}})
+}
+
+func TestObject_WithIDIndex(t *testing.T) {
+	var (
+		me   = []byte("me")
+		you  = []byte("you")
+		obj  = NewObject(encoding.RawTypeVarInt, WithMaxPageSize(8), WithIDIndex())
+		ref  = NewID(0, nil)
+		want []rune
+	)
+
+	// interleave two actors so ranges split across pages
+	for i := int64(1); i <= 200; i++ {
+		actor := me
+		if i%3 == 0 {
+			actor = you
+		}
+		op := Op{
+			ID:    NewID(i, actor),
+			Ref:   ref,
+			Value: encoding.RuneValue('a' + rune(i%26)),
+		}
+		if _, err := obj.Apply(op); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		want = append(want, 'a'+rune(i%26))
+		ref = op.ID
+	}
+
+	if want, got := string(want), string(readAllRunes(t, obj)); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	for i := int64(1); i <= 200; i++ {
+		actor := me
+		if i%3 == 0 {
+			actor = you
+		}
+		loc, err := obj.findPageIndex(NewID(i, actor))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := i-1, loc.Offset; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
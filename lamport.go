@@ -0,0 +1,39 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+// lamport allocates lamport timestamps for the local actor.  counter tracks the largest
+// counter seen from any actor so newly allocated ids order after everything observed.
+type lamport struct {
+	actor   []byte
+	counter int64
+}
+
+func newLamport(actor []byte) *lamport {
+	return &lamport{actor: actor}
+}
+
+// Next allocates the next id for the local actor
+func (l *lamport) Next() ID {
+	l.counter++
+	return NewID(l.counter, l.actor)
+}
+
+// Observe records a counter seen from any actor
+func (l *lamport) Observe(counter int64) {
+	if counter > l.counter {
+		l.counter = counter
+	}
+}
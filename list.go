@@ -0,0 +1,137 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"fmt"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+const (
+	ListInsert = sequenceInsert
+	ListDelete = sequenceDelete
+	ListSet    = 2
+)
+
+func isListSet(opType int64) bool {
+	return opType == ListSet
+}
+
+// List is a sequence of arbitrary values.  As a page holds a single raw type, values are
+// stored as byte arrays prefixed by their raw type.
+type List struct {
+	*sequence
+}
+
+// NewList returns an empty List whose local edits are attributed to actor
+func NewList(actor []byte, opts ...ObjectOption) *List {
+	return newList(newLamport(actor), ID{}, opts...)
+}
+
+func newList(clock *lamport, id ID, opts ...ObjectOption) *List {
+	opts = append(opts, WithAssignFunc(isListSet))
+	return &List{
+		sequence: newSequence(clock, id, encoding.RawTypeByteArray, opts...),
+	}
+}
+
+func encodeListValue(value encoding.Value) (encoding.Value, error) {
+	data, err := appendValue(nil, value)
+	if err != nil {
+		return encoding.Value{}, err
+	}
+	return encoding.ByteSliceValue(data), nil
+}
+
+// Insert inserts values before the visible element at index
+func (l *List) Insert(index int, values ...encoding.Value) error {
+	encoded := make([]encoding.Value, 0, len(values))
+	for _, value := range values {
+		v, err := encodeListValue(value)
+		if err != nil {
+			return fmt.Errorf("unable to insert at %v: %w", index, err)
+		}
+		encoded = append(encoded, v)
+	}
+	return l.insertAt(index, encoded...)
+}
+
+// Delete deletes the visible element at index
+func (l *List) Delete(index int) error {
+	return l.deleteAt(index, 1)
+}
+
+// Set replaces the value of the visible element at index.  The set references the element
+// rather than its position so, of concurrent sets of the same element, the set with the
+// greatest id wins.
+func (l *List) Set(index int, value encoding.Value) error {
+	v, err := encodeListValue(value)
+	if err != nil {
+		return fmt.Errorf("unable to set %v: %w", index, err)
+	}
+
+	target, err := l.obj.IDAt(int64(index))
+	if err != nil {
+		return fmt.Errorf("unable to set %v: %w", index, err)
+	}
+
+	return l.applyLocal(Op{
+		ID:    l.clock.Next(),
+		Ref:   target,
+		Type:  ListSet,
+		Value: v,
+		Obj:   l.id,
+	})
+}
+
+// Get returns the visible element at index
+func (l *List) Get(index int) (encoding.Value, error) {
+	v, err := l.obj.ValueAt(int64(index))
+	if err != nil {
+		return encoding.Value{}, fmt.Errorf("unable to get %v: %w", index, err)
+	}
+
+	value, _, err := readValue(v.Bytes)
+	if err != nil {
+		return encoding.Value{}, fmt.Errorf("unable to get %v: %w", index, err)
+	}
+	return value, nil
+}
+
+// Values returns the visible elements of the list
+func (l *List) Values() ([]encoding.Value, error) {
+	var values []encoding.Value
+	var readErr error
+	err := l.forEach(func(v encoding.Value) {
+		if readErr != nil {
+			return
+		}
+
+		value, _, err := readValue(v.Bytes)
+		if err != nil {
+			readErr = err
+			return
+		}
+		values = append(values, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return values, nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+func TestList(t *testing.T) {
+	list := NewList([]byte("me"), WithMaxPageSize(4))
+
+	if err := list.Insert(0, encoding.StringValue("a"), encoding.Int64Value(2), encoding.StringValue("c")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := list.Insert(3, encoding.ByteSliceValue([]byte{4})); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := 4, list.Len(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Run("get", func(t *testing.T) {
+		v, err := list.Get(1)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := encoding.RawTypeVarInt, v.RawType; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := int64(2), v.Int; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+
+		if _, err := list.Get(4); !errors.Is(err, io.EOF) {
+			t.Fatalf("got %v; want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("set", func(t *testing.T) {
+		if err := list.Set(2, encoding.StringValue("C")); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		v, err := list.Get(2)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := "C", string(v.Bytes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := encoding.ValueTypeUTF8, v.Type; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := 4, list.Len(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if err := list.Delete(0); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		values, err := list.Values()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := 3, len(values); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := int64(2), values[0].Int; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := "C", string(values[1].Bytes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := []byte{4}, values[2].Bytes; string(got) != string(want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := encoding.ValueTypeBytes, values[2].ValueType(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestList_ValueTypes(t *testing.T) {
+	values := []encoding.Value{
+		encoding.StringValue("a"),
+		encoding.ByteSliceValue([]byte{1}),
+		encoding.Int64Value(-2),
+		encoding.Float64Value(1.5),
+		encoding.BoolValue(true),
+		encoding.NullValue(),
+		encoding.TimestampValue(time.Unix(1, 0)),
+		encoding.CounterValue(3),
+	}
+
+	list := NewList([]byte("me"))
+	if err := list.Insert(0, values...); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	got, err := list.Values()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := len(values), len(got); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, want := range values {
+		if got := got[i]; got.Type != want.Type || got.ValueType() != want.ValueType() {
+			t.Fatalf("got %v, want %v", got.ValueType(), want.ValueType())
+		}
+	}
+}
+
+func TestList_ConcurrentSet(t *testing.T) {
+	for _, pageSize := range []int64{2, defaultRowCount} {
+		a := NewList([]byte("a"), WithMaxPageSize(pageSize))
+		b := NewList([]byte("b"), WithMaxPageSize(pageSize))
+
+		if err := a.Insert(0, encoding.Int64Value(1), encoding.Int64Value(2), encoding.Int64Value(3)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		exchangeOps(t, a.sequence, b.sequence)
+
+		// both sets have counter 4; the tie is broken by actor so b wins
+		if err := a.Set(1, encoding.StringValue("a")); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := b.Set(1, encoding.StringValue("b")); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := a.Set(2, encoding.StringValue("c")); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		exchangeOps(t, a.sequence, b.sequence)
+
+		for _, list := range []*List{a, b} {
+			values, err := list.Values()
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if want, got := 3, len(values); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if want, got := "b", string(values[1].Bytes); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if want, got := "c", string(values[2].Bytes); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+
+			v, err := list.Get(1)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if want, got := "b", string(v.Bytes); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		}
+
+		// deleting the element removes it regardless of its sets
+		if err := a.Delete(1); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		exchangeOps(t, a.sequence, b.sequence)
+		if want, got := 2, b.Len(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
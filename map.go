@@ -0,0 +1,250 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+const (
+	MapSet      = 0
+	MapDelete   = 1
+	MapMakeMap  = 2 // MapMakeMap assigns a new nested Map to the key
+	MapMakeList = 3 // MapMakeList assigns a new nested List to the key
+	MapMakeText = 4 // MapMakeText assigns a new nested Text to the key
+)
+
+// MapEntry holds a value assigned to a map key along with the id of the op that assigned it.
+// Type holds the op type; for the make types, ID is also the id of the nested object.
+type MapEntry struct {
+	ID    ID
+	Type  int64
+	Value encoding.Value
+}
+
+// Map is a key value object.  Each op records the key it assigns along with the op it
+// supersedes, Op.Ref.  Values that have not been superseded are retained; when concurrent
+// assignments leave more than one, the value with the greatest id wins and the others
+// are reported as conflicts.
+type Map struct {
+	id     ID // id of the map within a document
+	clock  *lamport
+	obj    *Object
+	values map[string][]MapEntry // values not yet superseded, winner first
+	record func(op Op)           // record, when set, receives each local op once applied
+}
+
+// NewMap returns an empty Map whose local edits are attributed to actor
+func NewMap(actor []byte, opts ...ObjectOption) *Map {
+	return newMap(newLamport(actor), ID{}, opts...)
+}
+
+func newMap(clock *lamport, id ID, opts ...ObjectOption) *Map {
+	return &Map{
+		id:     id,
+		clock:  clock,
+		obj:    NewObject(encoding.RawTypeByteArray, opts...),
+		values: map[string][]MapEntry{},
+	}
+}
+
+// Apply applies an op, local or remote, to the map
+func (m *Map) Apply(op Op) error {
+	key, value, err := decodeMapEntry(op.Value.Bytes)
+	if err != nil {
+		return fmt.Errorf("unable to apply op (%v,%v): %w", op.ID.Counter, op.ID.Actor, err)
+	}
+
+	if _, err := m.obj.Apply(op); err != nil {
+		return err
+	}
+	m.clock.Observe(op.ID.Counter)
+
+	entries := m.values[key]
+	for i, entry := range entries {
+		if entry.ID.Equal(op.Ref) {
+			entries = append(entries[:i], entries[i+1:]...)
+			break
+		}
+	}
+
+	if op.Type != MapDelete {
+		entries = append(entries, MapEntry{ID: op.ID, Type: op.Type, Value: value})
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].ID.Compare(entries[j].ID) > 0
+		})
+	}
+
+	if len(entries) == 0 {
+		delete(m.values, key)
+		return nil
+	}
+	m.values[key] = entries
+	return nil
+}
+
+// applyLocal applies an op generated by the local actor
+func (m *Map) applyLocal(op Op) error {
+	if err := m.Apply(op); err != nil {
+		return err
+	}
+	if m.record != nil {
+		m.record(op)
+	}
+	return nil
+}
+
+// Set assigns value to key superseding all values currently held by key, including
+// conflicts
+func (m *Map) Set(key string, value encoding.Value) error {
+	_, err := m.assign(key, MapSet, value)
+	return err
+}
+
+// assign records an op of opType assigning value to key superseding all values currently
+// held by key and returns the id of the op
+func (m *Map) assign(key string, opType int64, value encoding.Value) (ID, error) {
+	var ref ID
+	if entries := m.values[key]; len(entries) > 0 {
+		ref = entries[0].ID
+		if err := m.supersede(key, entries[1:]); err != nil {
+			return ID{}, err
+		}
+	}
+
+	data, err := encodeMapEntry(key, value)
+	if err != nil {
+		return ID{}, err
+	}
+
+	op := Op{
+		ID:    m.clock.Next(),
+		Ref:   ref,
+		Type:  opType,
+		Value: encoding.ByteSliceValue(data),
+		Obj:   m.id,
+	}
+	if err := m.applyLocal(op); err != nil {
+		return ID{}, err
+	}
+	return op.ID, nil
+}
+
+// Delete removes key along with any conflicting values
+func (m *Map) Delete(key string) error {
+	return m.supersede(key, m.values[key])
+}
+
+func (m *Map) supersede(key string, entries []MapEntry) error {
+	data, err := encodeMapEntry(key, encoding.Value{})
+	if err != nil {
+		return err
+	}
+
+	refs := make([]ID, 0, len(entries))
+	for _, entry := range entries {
+		refs = append(refs, entry.ID)
+	}
+
+	for _, ref := range refs {
+		op := Op{
+			ID:    m.clock.Next(),
+			Ref:   ref,
+			Type:  MapDelete,
+			Value: encoding.ByteSliceValue(data),
+			Obj:   m.id,
+		}
+		if err := m.applyLocal(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Get returns the winning value for key
+func (m *Map) Get(key string) (encoding.Value, bool) {
+	entries := m.values[key]
+	if len(entries) == 0 {
+		return encoding.Value{}, false
+	}
+	return entries[0].Value, true
+}
+
+// entry returns the winning entry for key
+func (m *Map) entry(key string) (MapEntry, bool) {
+	entries := m.values[key]
+	if len(entries) == 0 {
+		return MapEntry{}, false
+	}
+	return entries[0], true
+}
+
+// Conflicts returns all values currently held by key, winner first.  More than one
+// value indicates key was assigned concurrently.
+func (m *Map) Conflicts(key string) []MapEntry {
+	entries := m.values[key]
+	if len(entries) == 0 {
+		return nil
+	}
+	return append([]MapEntry(nil), entries...)
+}
+
+// Keys returns the keys currently held by the map in sorted order
+func (m *Map) Keys() []string {
+	keys := make([]string, 0, len(m.values))
+	for key := range m.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Len returns the number of keys held by the map
+func (m *Map) Len() int {
+	return len(m.values)
+}
+
+// encodeMapEntry encodes to:
+// * var int key length
+// * key bytes
+// * value encoded by appendValue
+func encodeMapEntry(key string, value encoding.Value) ([]byte, error) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], int64(len(key)))
+
+	data := make([]byte, 0, n+len(key)+1+value.Length())
+	data = append(data, buf[:n]...)
+	data = append(data, key...)
+	return appendValue(data, value)
+}
+
+func decodeMapEntry(data []byte) (string, encoding.Value, error) {
+	length, n := binary.Varint(data)
+	if n <= 0 || length < 0 || int64(len(data)-n) < length {
+		return "", encoding.Value{}, fmt.Errorf("unable to decode map key: %w", io.ErrUnexpectedEOF)
+	}
+
+	key := string(data[n : n+int(length)])
+	value, _, err := readValue(data[n+int(length):])
+	if err != nil {
+		return "", encoding.Value{}, fmt.Errorf("unable to decode value for key, %v: %w", key, err)
+	}
+	return key, value, nil
+}
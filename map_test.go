@@ -0,0 +1,186 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+func TestMap_Set(t *testing.T) {
+	m := NewMap([]byte("me"))
+	if err := m.Set("a", encoding.StringValue("hello")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := m.Set("b", encoding.Int64Value(123)); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := m.Set("a", encoding.StringValue("world")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	got, ok := m.Get("a")
+	if !ok {
+		t.Fatalf("got false; want true")
+	}
+	if want, got := "world", string(got.Bytes); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := encoding.ValueTypeUTF8, got.Type; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	got, ok = m.Get("b")
+	if !ok {
+		t.Fatalf("got false; want true")
+	}
+	if want, got := int64(123), got.Int; got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if want, got := []string{"a", "b"}, m.Keys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := 1, len(m.Conflicts("a")); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMap_Delete(t *testing.T) {
+	m := NewMap([]byte("me"))
+	if err := m.Set("a", encoding.StringValue("hello")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := m.Delete("a"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if _, ok := m.Get("a"); ok {
+		t.Fatalf("got true; want false")
+	}
+	if want, got := 0, m.Len(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// deleting a missing key is a no-op
+	if err := m.Delete("b"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+}
+
+func TestMap_Conflicts(t *testing.T) {
+	var (
+		a = NewMap([]byte("a"))
+		b = NewMap([]byte("b"))
+	)
+
+	if err := a.Set("key", encoding.StringValue("a")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Set("key", encoding.StringValue("b")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	exchangeMapOps(t, a, b)
+
+	for _, m := range []*Map{a, b} {
+		got, _ := m.Get("key")
+		if want, got := "b", string(got.Bytes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+
+		conflicts := m.Conflicts("key")
+		if want, got := 2, len(conflicts); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := "a", string(conflicts[1].Value.Bytes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	// a subsequent assignment resolves the conflict
+	if err := a.Set("key", encoding.StringValue("resolved")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	exchangeMapOps(t, a, b)
+
+	for _, m := range []*Map{a, b} {
+		got, _ := m.Get("key")
+		if want, got := "resolved", string(got.Bytes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := 1, len(m.Conflicts("key")); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestMap_ConcurrentDelete(t *testing.T) {
+	var (
+		a = NewMap([]byte("a"))
+		b = NewMap([]byte("b"))
+	)
+
+	if err := a.Set("key", encoding.StringValue("v1")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	exchangeMapOps(t, a, b)
+
+	// concurrent update and delete; the update survives
+	if err := a.Set("key", encoding.StringValue("v2")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Delete("key"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	exchangeMapOps(t, a, b)
+
+	for _, m := range []*Map{a, b} {
+		got, ok := m.Get("key")
+		if !ok {
+			t.Fatalf("got false; want true")
+		}
+		if want, got := "v2", string(got.Bytes); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// exchangeMapOps applies to each map the ops held only by the other
+func exchangeMapOps(t *testing.T, a, b *Map) {
+	aOps, bOps := readAllOps(t, a.obj), readAllOps(t, b.obj)
+	applyMissing := func(m *Map, have, ops []Op) {
+		var missing []Op
+		for _, op := range ops {
+			found := false
+			for _, h := range have {
+				found = found || h.ID.Equal(op.ID)
+			}
+			if !found {
+				missing = append(missing, op)
+			}
+		}
+
+		sort.Slice(missing, func(i, j int) bool { return missing[i].ID.Compare(missing[j].ID) < 0 })
+		for _, op := range missing {
+			if err := m.Apply(op); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+	}
+	applyMissing(a, aOps, bOps)
+	applyMissing(b, bOps, aOps)
+}
@@ -1,54 +1,231 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
 package automerge
 
+// node is a weight annotated, height balanced (AVL) tree over the pages of an Object.
+// Leaves correspond, in order, to pages.  Internal nodes carry the totals of their
+// subtrees which allows visible positions and row offsets to be resolved to a page
+// in O(log n) rather than by scanning every page.
 type node struct {
 	left, right *node
-	weight      int64
-	deletes     int64
-	page        *Page
-	isDelete    func(int64) bool
-	maxRowCount int64
+	parent      *node // parent is only meaningful below the root; see position
+	height      int
+	pages       int   // number of pages beneath this node
+	weight      int64 // number of visible elements beneath this node
+	rows        int64 // number of rows, including deletes, beneath this node
+}
+
+func newLeaf(weight, rows int64) *node {
+	return &node{
+		pages:  1,
+		weight: weight,
+		rows:   rows,
+	}
 }
 
-func (n *node) insertLocal(index int64, op Op) error {
-	switch n.deletes {
+// buildTree returns a balanced tree whose leaves hold the weights and rows provided
+func buildTree(weights, rows []int64) *node {
+	switch len(weights) {
 	case 0:
-		if err := n.page.InsertAt(index, op); err != nil {
-			return err
+		return nil
+	case 1:
+		return newLeaf(weights[0], rows[0])
+	}
+
+	mid := len(weights) / 2
+	return newBranch(buildTree(weights[:mid], rows[:mid]), buildTree(weights[mid:], rows[mid:]))
+}
+
+func newBranch(left, right *node) *node {
+	n := &node{left: left, right: right}
+	n.update()
+	return n
+}
+
+func (n *node) isLeaf() bool {
+	return n.left == nil
+}
+
+func (n *node) update() {
+	n.left.parent = n
+	n.right.parent = n
+	n.height = max(n.left.height, n.right.height) + 1
+	n.pages = n.left.pages + n.right.pages
+	n.weight = n.left.weight + n.right.weight
+	n.rows = n.left.rows + n.right.rows
+}
+
+// find returns the index of the page containing the visible element at pos along with
+// the position of the element within that page.  Positions beyond the last element
+// resolve to the end of the last page.
+func (n *node) find(pos int64) (pageIndex int, offset int64) {
+	for !n.isLeaf() {
+		if pos < n.left.weight {
+			n = n.left
+			continue
 		}
-	default:
-		if err := n.page.InsertAtTranslated(index, op, n.isDelete); err != nil {
-			return err
+		pos -= n.left.weight
+		pageIndex += n.left.pages
+		n = n.right
+	}
+	return pageIndex, pos
+}
+
+// offset returns the number of visible elements and rows that precede the page
+func (n *node) offset(pageIndex int) (visible, rows int64) {
+	for !n.isLeaf() {
+		if pageIndex < n.left.pages {
+			n = n.left
+			continue
 		}
+		visible += n.left.weight
+		rows += n.left.rows
+		pageIndex -= n.left.pages
+		n = n.right
 	}
+	return visible, rows
+}
 
-	if n.isDelete(op.Type) {
-		n.deletes++
+// leaf returns the leaf for the page
+func (n *node) leaf(pageIndex int) *node {
+	for !n.isLeaf() {
+		if pageIndex < n.left.pages {
+			n = n.left
+			continue
+		}
+		pageIndex -= n.left.pages
+		n = n.right
 	}
-	n.weight = n.page.rowCount - n.deletes<<1
+	return n
+}
 
-	return nil
+// position returns the index of the page held by leaf.  Leaves remain in place as pages
+// around them are split, merged and rebalanced, so a leaf serves as a stable identity for
+// its page.
+func (n *node) position(leaf *node) int {
+	var pageIndex int
+	for ; leaf != n; leaf = leaf.parent {
+		if parent := leaf.parent; parent.right == leaf {
+			pageIndex += parent.left.pages
+		}
+	}
+	return pageIndex
 }
 
-func (n *node) length() int64 {
-	if n == nil {
-		return 0
+// set replaces the weight and rows of the page
+func (n *node) set(pageIndex int, weight, rows int64) {
+	if n.isLeaf() {
+		n.weight = weight
+		n.rows = rows
+		return
+	}
+
+	if pageIndex < n.left.pages {
+		n.left.set(pageIndex, weight, rows)
+	} else {
+		n.right.set(pageIndex-n.left.pages, weight, rows)
 	}
-	return n.left.length() + n.right.length() + n.weight - n.deletes<<1
+	n.update()
 }
 
-func (n *node) InsertAt(index int64, op Op) error {
-	switch {
-	case n.left == nil || n.right == nil:
-		return n.insertLocal(index, op)
+// split replaces the page with two adjacent pages and returns the rebalanced tree.  Passing
+// the existing leaf as left retains the identity of the page.
+func (n *node) split(pageIndex int, left, right *node) *node {
+	if n.isLeaf() {
+		return newBranch(left, right)
+	}
 
-	case index <= n.weight:
-		if err := n.left.InsertAt(index-n.weight, op); err != nil {
-			return err
-		}
-		n.weight = n.left.length()
+	if pageIndex < n.left.pages {
+		n.left = n.left.split(pageIndex, left, right)
+	} else {
+		n.right = n.right.split(pageIndex-n.left.pages, left, right)
+	}
+	n.update()
+	return n.rebalance()
+}
+
+// remove deletes the leaf at pageIndex and returns the new root; nil if n was that leaf
+func (n *node) remove(pageIndex int) *node {
+	if n.isLeaf() {
 		return nil
+	}
+
+	if pageIndex < n.left.pages {
+		n.left = n.left.remove(pageIndex)
+		if n.left == nil {
+			return n.right
+		}
+	} else {
+		n.right = n.right.remove(pageIndex - n.left.pages)
+		if n.right == nil {
+			return n.left
+		}
+	}
+	n.update()
+	return n.rebalance()
+}
+
+func (n *node) balance() int {
+	return n.left.height - n.right.height
+}
 
+func (n *node) rebalance() *node {
+	switch b := n.balance(); {
+	case b > 1:
+		if n.left.balance() < 0 {
+			n.left = n.left.rotateLeft()
+		}
+		return n.rotateRight()
+	case b < -1:
+		if n.right.balance() > 0 {
+			n.right = n.right.rotateRight()
+		}
+		return n.rotateLeft()
 	default:
-		return n.right.InsertAt(index-n.weight, op)
+		return n
+	}
+}
+
+func (n *node) rotateLeft() *node {
+	r := n.right
+	n.right = r.left
+	n.update()
+	r.left = n
+	r.update()
+	return r
+}
+
+func (n *node) rotateRight() *node {
+	l := n.left
+	n.left = l.right
+	n.update()
+	l.right = n
+	l.update()
+	return l
+}
+
+func max(a, b int) int {
+	if a >= b {
+		return a
+	}
+	return b
+}
+
+func max64(a, b int64) int64 {
+	if a >= b {
+		return a
 	}
+	return b
 }
@@ -0,0 +1,159 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNode(t *testing.T) {
+	var (
+		rng     = rand.New(rand.NewSource(1))
+		weights = []int64{3}
+		rows    = []int64{4}
+		tree    = newLeaf(3, 4)
+	)
+
+	verify := func(t *testing.T) {
+		if want, got := len(weights), tree.pages; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+
+		// AVL trees are no taller than ~1.44 log2(n)
+		if limit := int(1.45*math.Log2(float64(len(weights)+2))) + 1; tree.height > limit {
+			t.Fatalf("got height %v; want <= %v", tree.height, limit)
+		}
+
+		var visible, rowOffset int64
+		for i := range weights {
+			gotVisible, gotRows := tree.offset(i)
+			if gotVisible != visible || gotRows != rowOffset {
+				t.Fatalf("got offset (%v,%v); want (%v,%v)", gotVisible, gotRows, visible, rowOffset)
+			}
+
+			for j := int64(0); j < weights[i]; j++ {
+				pageIndex, offset := tree.find(visible + j)
+				if pageIndex != i || offset != j {
+					t.Fatalf("got find (%v,%v); want (%v,%v)", pageIndex, offset, i, j)
+				}
+			}
+
+			visible += weights[i]
+			rowOffset += rows[i]
+		}
+		if tree.weight != visible || tree.rows != rowOffset {
+			t.Fatalf("got totals (%v,%v); want (%v,%v)", tree.weight, tree.rows, visible, rowOffset)
+		}
+	}
+
+	for i := 0; i < 500; i++ {
+		pageIndex := rng.Intn(len(weights))
+		if rng.Intn(3) == 0 {
+			w := rng.Int63n(10)
+			r := w + rng.Int63n(5)
+			weights[pageIndex], rows[pageIndex] = w, r
+			tree.set(pageIndex, w, r)
+			continue
+		}
+
+		lw, rw := rng.Int63n(10), rng.Int63n(10)
+		lr, rr := lw+rng.Int63n(3), rw+rng.Int63n(3)
+		weights = append(weights[:pageIndex], append([]int64{lw, rw}, weights[pageIndex+1:]...)...)
+		rows = append(rows[:pageIndex], append([]int64{lr, rr}, rows[pageIndex+1:]...)...)
+		tree = tree.split(pageIndex, newLeaf(lw, lr), newLeaf(rw, rr))
+	}
+	verify(t)
+
+	tree = buildTree(weights, rows)
+	verify(t)
+}
+
+func TestNode_Remove(t *testing.T) {
+	var (
+		rng     = rand.New(rand.NewSource(1))
+		weights []int64
+		rows    []int64
+	)
+	for i := 0; i < 200; i++ {
+		w := rng.Int63n(10)
+		weights = append(weights, w)
+		rows = append(rows, w+rng.Int63n(3))
+	}
+	tree := buildTree(weights, rows)
+
+	for len(weights) > 1 {
+		pageIndex := rng.Intn(len(weights))
+		weights = append(weights[:pageIndex], weights[pageIndex+1:]...)
+		rows = append(rows[:pageIndex], rows[pageIndex+1:]...)
+		tree = tree.remove(pageIndex)
+
+		if want, got := len(weights), tree.pages; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if limit := int(1.45*math.Log2(float64(len(weights)+2))) + 1; tree.height > limit {
+			t.Fatalf("got height %v; want <= %v", tree.height, limit)
+		}
+
+		var visible, rowOffset int64
+		for i := range weights {
+			if gotVisible, gotRows := tree.offset(i); gotVisible != visible || gotRows != rowOffset {
+				t.Fatalf("got offset (%v,%v); want (%v,%v)", gotVisible, gotRows, visible, rowOffset)
+			}
+			visible += weights[i]
+			rowOffset += rows[i]
+		}
+		if tree.weight != visible || tree.rows != rowOffset {
+			t.Fatalf("got totals (%v,%v); want (%v,%v)", tree.weight, tree.rows, visible, rowOffset)
+		}
+	}
+
+	if got := tree.remove(0); got != nil {
+		t.Fatalf("got %v; want nil", got)
+	}
+}
+
+func TestNode_Position(t *testing.T) {
+	var (
+		rng    = rand.New(rand.NewSource(1))
+		tree   = newLeaf(0, 0)
+		leaves = []*node{tree}
+	)
+	verify := func(t *testing.T) {
+		for i, leaf := range leaves {
+			if got := tree.position(leaf); got != i {
+				t.Fatalf("got %v, want %v", got, i)
+			}
+			if got := tree.leaf(i); got != leaf {
+				t.Fatalf("got %p, want %p", got, leaf)
+			}
+		}
+	}
+
+	for i := 0; i < 500; i++ {
+		pageIndex := rng.Intn(len(leaves))
+		if len(leaves) > 1 && rng.Intn(3) == 0 {
+			leaves = append(leaves[:pageIndex], leaves[pageIndex+1:]...)
+			tree = tree.remove(pageIndex)
+		} else {
+			// the left half retains the leaf of the page split
+			right := newLeaf(0, 0)
+			leaves = append(leaves[:pageIndex+1], append([]*node{right}, leaves[pageIndex+1:]...)...)
+			tree = tree.split(pageIndex, leaves[pageIndex], right)
+		}
+		verify(t)
+	}
+}
@@ -18,11 +18,20 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 
 	"github.com/savaki/automerge/encoding"
 	"github.com/willf/bloom"
 )
 
+// ErrRefNotFound indicates an op references an id the object does not hold, typically
+// because the referenced op has not yet been delivered
+var ErrRefNotFound = errors.New("ref not found")
+
+// ErrInvalidRef indicates an op references an id it may not reference, such as a delete
+// referencing the start of the object or another delete
+var ErrInvalidRef = errors.New("invalid ref")
+
 const (
 	defaultRowCount = 200
 	defaultBloomM   = 15000
@@ -35,8 +44,15 @@ type bloomOptions struct {
 }
 
 type objectOptions struct {
-	Bloom       bloomOptions
-	MaxPageSize int64
+	Bloom          bloomOptions
+	IDIndex        bool
+	IsAssign       func(opType int64) bool
+	IsAttached     func(opType int64) bool // deletes and assigns; derived by makeObjectOptions
+	IsDelete       func(opType int64) bool
+	MaxPageSize    int64
+	MinPageSize    int64
+	PersistFilters bool
+	SkipInterval   int
 }
 
 type location struct {
@@ -51,6 +67,9 @@ type Object struct {
 	pages   []*Page
 	filters []*bloom.BloomFilter
 	rawType encoding.RawType
+	tree    *node    // index of visible elements and rows by page
+	index   *idIndex // optional exact index of ids by page
+	clock   Clock    // largest counter applied per actor
 
 	last struct {
 		Filter       *bloom.BloomFilter
@@ -61,6 +80,11 @@ type Object struct {
 	}
 }
 
+type OpToken struct {
+	PageToken
+	pageIndex int
+}
+
 type ValueToken struct {
 	PageValueToken
 	pageIndex int
@@ -77,6 +101,15 @@ func makeObjectOptions(opts ...ObjectOption) objectOptions {
 	for _, opt := range opts {
 		opt(&options)
 	}
+
+	switch isDelete, isAssign := options.IsDelete, options.IsAssign; {
+	case isAssign == nil:
+		options.IsAttached = isDelete
+	case isDelete == nil:
+		options.IsAttached = isAssign
+	default:
+		options.IsAttached = func(opType int64) bool { return isDelete(opType) || isAssign(opType) }
+	}
 	return options
 }
 
@@ -93,6 +126,18 @@ func WithMaxPageSize(n int64) ObjectOption {
 	}
 }
 
+// WithMinPageSize merges a page holding fewer than n records with a neighbouring page
+// provided the merged page holds fewer than the maximum page size.  Pages are merged after
+// splits, by ReadObject, and by Compact.  By default, pages are never merged.
+func WithMinPageSize(n int64) ObjectOption {
+	return func(o *objectOptions) {
+		if n <= 0 {
+			return
+		}
+		o.MinPageSize = n
+	}
+}
+
 func WithBloomOptions(m, k uint) ObjectOption {
 	return func(o *objectOptions) {
 		if m <= 0 || k <= 0 {
@@ -105,40 +150,81 @@ func WithBloomOptions(m, k uint) ObjectOption {
 	}
 }
 
+// WithPersistedFilters writes bloom filters alongside pages in Object.WriteTo so they
+// need not be rebuilt by ReadObject
+func WithPersistedFilters() ObjectOption {
+	return func(o *objectOptions) {
+		o.PersistFilters = true
+	}
+}
+
+// WithDeleteFunc identifies op types that delete the element referenced by Op.Ref.  Delete
+// ops are stored immediately after the element they tombstone and neither the delete nor
+// the deleted element are returned by NextValue.
+func WithDeleteFunc(isDelete func(opType int64) bool) ObjectOption {
+	return func(o *objectOptions) {
+		o.IsDelete = isDelete
+	}
+}
+
+// WithAssignFunc identifies op types that replace the value of the element referenced by
+// Op.Ref.  Like deletes, assigns are stored after the element they reference.  The value
+// of the element is that of its greatest assign or, if there are none, that of the op that
+// inserted it.
+func WithAssignFunc(isAssign func(opType int64) bool) ObjectOption {
+	return func(o *objectOptions) {
+		o.IsAssign = isAssign
+	}
+}
+
+// WithIDIndex maintains an exact index from id to page.  Lookups no longer test the bloom
+// filter of each page and are O(log n) with no false positives at the cost of additional
+// memory.  Bloom filters are still maintained for persistence and sync.
+func WithIDIndex() ObjectOption {
+	return func(o *objectOptions) {
+		o.IDIndex = true
+	}
+}
+
+// WithSkipIndex maintains, within each page column, the byte position of every interval
+// runs or values so seeks within a page need not scan from the start.  Larger values of
+// WithMaxPageSize become practical at the cost of a little memory per page.
+func WithSkipIndex(interval int) ObjectOption {
+	return func(o *objectOptions) {
+		if interval <= 0 {
+			return
+		}
+		o.SkipInterval = interval
+	}
+}
+
 // NewObject returns a new object whose value is of RawType using the options provided
 func NewObject(rawType encoding.RawType, opts ...ObjectOption) *Object {
 	options := makeObjectOptions(opts...)
 	filter, _ := makeBloomFilter(options.Bloom, nil)
-	return &Object{
+	page := NewPage(rawType)
+	if options.SkipInterval > 0 {
+		page.enableSkipIndex(options.SkipInterval)
+	}
+	obj := &Object{
 		options: options,
-		pages:   []*Page{NewPage(rawType)},
+		pages:   []*Page{page},
 		filters: []*bloom.BloomFilter{filter},
 		rawType: rawType,
+		tree:    newLeaf(0, 0),
+		clock:   Clock{},
+	}
+	if options.IDIndex {
+		obj.index = newIDIndex()
 	}
+	return obj
 }
 
 // findPageIndex accepts an id and returns the index within r.pages
 func (o *Object) findPageIndex(id ID) (location, error) {
-	var key *bloomKey
-	if o.last.Ok {
-		// many times, the next edit will follow the previous
-		if o.last.ID.Equal(id) {
-			return o.last.Location, nil
-		}
-
-		// even if not directly next, they next edit is often close to the previous
-		key = makeBloomKey(id.Counter, id.Actor)
-		defer key.Free()
-		if o.last.Filter.Test(key.data) {
-			page := o.pages[o.last.Location.PageIndex]
-			if index, err := page.FindIndex(id.Counter, id.Actor); err == nil {
-				return location{
-					Offset:    o.last.FilterOffset + index,
-					PageIndex: o.last.Location.PageIndex,
-					OpIndex:   index,
-				}, nil
-			}
-		}
+	// many times, the next edit will follow the previous
+	if o.last.Ok && o.last.ID.Equal(id) {
+		return o.last.Location, nil
 	}
 
 	if id.Counter == 0 && len(id.Actor) == 0 {
@@ -149,9 +235,39 @@ func (o *Object) findPageIndex(id ID) (location, error) {
 		}, nil
 	}
 
-	if key == nil {
-		key = makeBloomKey(id.Counter, id.Actor)
-		defer key.Free()
+	if o.index != nil {
+		leaf, ok := o.index.Find(id)
+		if !ok {
+			return location{}, io.EOF
+		}
+		pageIndex := o.tree.position(leaf)
+
+		index, err := o.pages[pageIndex].FindIndex(id.Counter, id.Actor)
+		if err != nil {
+			return location{}, fmt.Errorf("unable to find (%v,%v) in page, %v: index out of sync: %w", id.Counter, id.Actor, pageIndex, err)
+		}
+
+		_, offset := o.tree.offset(pageIndex)
+		return location{
+			Offset:    offset + index,
+			PageIndex: pageIndex,
+			OpIndex:   index,
+		}, nil
+	}
+
+	key := makeBloomKey(id.Counter, id.Actor)
+	defer key.Free()
+
+	// even if not directly next, they next edit is often close to the previous
+	if o.last.Ok && o.last.Filter.Test(key.data) {
+		page := o.pages[o.last.Location.PageIndex]
+		if index, err := page.FindIndex(id.Counter, id.Actor); err == nil {
+			return location{
+				Offset:    o.last.FilterOffset + index,
+				PageIndex: o.last.Location.PageIndex,
+				OpIndex:   index,
+			}, nil
+		}
 	}
 
 	var objectIndex int64
@@ -184,6 +300,16 @@ func (o *Object) splitPageAt(pageIndex int, index int64) error {
 	// todo - consider algorithms to split on other boundaries
 
 	page := o.pages[pageIndex]
+	index, err := page.splitIndex(index, o.options.IsAttached)
+	if err != nil {
+		return fmt.Errorf("unable to split page, %v: %w", pageIndex, err)
+	}
+	if index == 0 {
+		// page holds a single element and its attached ops; avoid rescanning it on every op
+		page.splitRows = page.rowCount + max64(o.options.MaxPageSize/2, 1)
+		return nil
+	}
+
 	left, right, err := page.SplitAt(index)
 	if err != nil {
 		return fmt.Errorf("unable to insert record: failed to split page, %v, at index, %v: %w", pageIndex, index, err)
@@ -199,6 +325,16 @@ func (o *Object) splitPageAt(pageIndex int, index int64) error {
 		return fmt.Errorf("unable to split page at index, %v: failed to update right bloom filter: %w", index, err)
 	}
 
+	leftVisible, err := left.Visible(o.options.IsAttached, o.options.IsDelete)
+	if err != nil {
+		return fmt.Errorf("unable to split page at index, %v: failed to count left elements: %w", index, err)
+	}
+
+	rightVisible, err := right.Visible(o.options.IsAttached, o.options.IsDelete)
+	if err != nil {
+		return fmt.Errorf("unable to split page at index, %v: failed to count right elements: %w", index, err)
+	}
+
 	o.pages = append(o.pages, nil)
 	o.filters = append(o.filters, nil)
 	for i := len(o.pages) - 1; i > pageIndex; i-- {
@@ -212,10 +348,249 @@ func (o *Object) splitPageAt(pageIndex int, index int64) error {
 	o.pages[pageIndex+1] = right
 	o.filters[pageIndex+1] = rightFilter
 
+	// the left page keeps the leaf of the page split so the id index need only reassign
+	// the ids moved to the right
+	leftLeaf, rightLeaf := o.tree.leaf(pageIndex), newLeaf(rightVisible, right.rowCount)
+	leftLeaf.weight, leftLeaf.rows = leftVisible, left.rowCount
+	o.tree = o.tree.split(pageIndex, leftLeaf, rightLeaf)
+
+	if o.index != nil {
+		if err := o.index.Split(right, rightLeaf); err != nil {
+			return fmt.Errorf("unable to split page at index, %v: failed to update id index: %w", index, err)
+		}
+	}
+
 	return nil
 }
 
-func (o *Object) NextValue(token ValueToken) (ValueToken, error) {
+// full returns true if page has grown large enough that it should be split.  Pages found
+// to be unsplittable are not reconsidered until they have grown further.
+func (o *Object) full(page *Page) bool {
+	return page.rowCount >= max64(o.options.MaxPageSize, page.splitRows)
+}
+
+// mergeable returns true if the pages at pageIndex and pageIndex+1 should be merged
+func (o *Object) mergeable(pageIndex int) bool {
+	if pageIndex < 0 || pageIndex+1 >= len(o.pages) {
+		return false
+	}
+
+	var (
+		left  = o.pages[pageIndex].rowCount
+		right = o.pages[pageIndex+1].rowCount
+		min   = o.options.MinPageSize
+	)
+	return (left < min || right < min) && left+right < o.options.MaxPageSize
+}
+
+// compact merges mergeable pages between from and to inclusive
+func (o *Object) compact(from, to int) error {
+	if from < 0 {
+		from = 0
+	}
+	for pageIndex := from; pageIndex < to; {
+		if !o.mergeable(pageIndex) {
+			pageIndex++
+			continue
+		}
+		if err := o.mergePages(pageIndex); err != nil {
+			return err
+		}
+		to--
+	}
+	return nil
+}
+
+// Compact merges adjacent pages where either holds fewer records than the minimum page
+// size set by WithMinPageSize
+func (o *Object) Compact() error {
+	return o.compact(0, len(o.pages)-1)
+}
+
+// mergePages replaces the pages at pageIndex and pageIndex+1 with a single page holding
+// the records of both
+func (o *Object) mergePages(pageIndex int) error {
+	left, right := o.pages[pageIndex], o.pages[pageIndex+1]
+	merged, err := left.Merge(right)
+	if err != nil {
+		return fmt.Errorf("unable to merge page, %v: %w", pageIndex, err)
+	}
+
+	filter, err := makeBloomFilter(o.options.Bloom, merged)
+	if err != nil {
+		return fmt.Errorf("unable to merge page, %v: failed to rebuild bloom filter: %w", pageIndex, err)
+	}
+
+	var (
+		weight = o.tree.leaf(pageIndex).weight + o.tree.leaf(pageIndex+1).weight
+		rows   = merged.rowCount
+	)
+
+	o.pages[pageIndex] = merged
+	o.filters[pageIndex] = filter
+	o.pages = append(o.pages[:pageIndex+1], o.pages[pageIndex+2:]...)
+	o.filters = append(o.filters[:pageIndex+1], o.filters[pageIndex+2:]...)
+
+	o.tree = o.tree.remove(pageIndex + 1)
+	o.tree.set(pageIndex, weight, rows)
+
+	if o.index != nil {
+		if err := o.index.Merge(right, o.tree.leaf(pageIndex)); err != nil {
+			return fmt.Errorf("unable to merge page, %v: failed to update id index: %w", pageIndex, err)
+		}
+	}
+
+	o.last.Ok = false // pages have been rearranged
+	return nil
+}
+
+// NextOp returns the next op stored in the object including deletes and deleted elements.
+// Byte slices within the op reference the underlying page and are only valid until the
+// object is next modified.
+func (o *Object) NextOp(token OpToken) (OpToken, error) {
+	page := o.pages[token.pageIndex]
+	pageToken, err := page.Next(token.PageToken)
+	if err != nil {
+		if token.pageIndex+1 >= len(o.pages) || !errors.Is(err, io.EOF) {
+			return OpToken{}, err
+		}
+
+		token.pageIndex++ // advance to next page
+		page = o.pages[token.pageIndex]
+		pageToken, err = page.Next(PageToken{})
+		if err != nil {
+			return OpToken{}, err
+		}
+	}
+
+	return OpToken{
+		PageToken: pageToken,
+		pageIndex: token.pageIndex,
+	}, nil
+}
+
+// Len returns the number of visible elements
+func (o *Object) Len() int64 {
+	return o.tree.weight
+}
+
+// IDAt returns the id of the visible element at pos
+func (o *Object) IDAt(pos int64) (ID, error) {
+	op, err := o.OpAt(pos)
+	if err != nil {
+		return ID{}, err
+	}
+	return op.ID, nil
+}
+
+// OpAt returns the op that inserted the visible element at pos.  As with NextOp, byte
+// slices within the op are only valid until the object is next modified.
+func (o *Object) OpAt(pos int64) (Op, error) {
+	op, _, err := o.elementAt(pos)
+	return op, err
+}
+
+// ValueAt returns the value of the visible element at pos; the value of its greatest
+// assign or, if it has none, the value it was inserted with
+func (o *Object) ValueAt(pos int64) (encoding.Value, error) {
+	_, value, err := o.elementAt(pos)
+	return value, err
+}
+
+// elementAt returns the op that inserted the visible element at pos along with its value
+func (o *Object) elementAt(pos int64) (Op, encoding.Value, error) {
+	if pos < 0 || pos >= o.tree.weight {
+		return Op{}, encoding.Value{}, fmt.Errorf("unable to find element at %v: %w", pos, io.EOF)
+	}
+
+	pageIndex, offset := o.tree.find(pos)
+
+	var (
+		page     = o.pages[pageIndex]
+		token    PageToken
+		err      error
+		visible  int64
+		pending  Op
+		value    encoding.Value
+		hasValue bool
+		assigned bool
+	)
+	for {
+		token, err = page.Next(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return Op{}, encoding.Value{}, fmt.Errorf("unable to find element at %v: %w", pos, err)
+		}
+
+		// an element is visible once we know it isn't followed by a delete.  attached ops
+		// are ordered greatest first so the first assign holds the value of the element.
+		if o.isAttached(token.Op.Type) {
+			switch {
+			case o.isDelete(token.Op.Type):
+				hasValue = false
+			case hasValue && !assigned:
+				value, assigned = token.Op.Value, true
+			}
+			continue
+		}
+		if hasValue {
+			if visible == offset {
+				return pending, value, nil
+			}
+			visible++
+		}
+		pending, value, hasValue, assigned = token.Op, token.Op.Value, true, false
+	}
+
+	if hasValue && visible == offset {
+		return pending, value, nil
+	}
+	return Op{}, encoding.Value{}, fmt.Errorf("unable to find element at %v: index out of sync with page, %v", pos, pageIndex)
+}
+
+// Position returns the visible position of the element identified by id.  Deleted
+// elements report the position of the visible element that follows them.
+func (o *Object) Position(id ID) (int64, error) {
+	loc, err := o.findPageIndex(id)
+	if err != nil {
+		return 0, fmt.Errorf("unable to find position of (%v,%v): %w", id.Counter, id.Actor, err)
+	}
+	if loc.OpIndex < 0 {
+		return 0, fmt.Errorf("unable to find position of (%v,%v): %w", id.Counter, id.Actor, io.EOF)
+	}
+
+	var (
+		pos, _   = o.tree.offset(loc.PageIndex)
+		page     = o.pages[loc.PageIndex]
+		token    PageToken
+		hasValue bool
+	)
+	for i := int64(0); i < loc.OpIndex; i++ {
+		token, err = page.Next(token)
+		if err != nil {
+			return 0, fmt.Errorf("unable to find position of (%v,%v): %w", id.Counter, id.Actor, err)
+		}
+
+		switch {
+		case !o.isAttached(token.Op.Type):
+			if hasValue {
+				pos++
+			}
+			hasValue = true
+		case o.isDelete(token.Op.Type):
+			hasValue = false
+		}
+	}
+	if hasValue {
+		pos++
+	}
+
+	return pos, nil
+}
+
+func (o *Object) nextRow(token ValueToken) (ValueToken, error) {
 	page := o.pages[token.pageIndex]
 	pvToken, err := page.NextValue(token.PageValueToken)
 	if err != nil {
@@ -237,56 +612,422 @@ func (o *Object) NextValue(token ValueToken) (ValueToken, error) {
 	}, nil
 }
 
+// NextValue returns the next visible value; delete ops along with the elements they
+// tombstone are skipped.  The value of an element that has been assigned is that of its
+// greatest assign.
+func (o *Object) NextValue(token ValueToken) (ValueToken, error) {
+	isAttached := o.options.IsAttached
+	if isAttached == nil {
+		return o.nextRow(token)
+	}
+
+	for {
+		next, err := o.nextRow(token)
+		if err != nil {
+			return ValueToken{}, err
+		}
+		token = next
+		if isAttached(next.OpType) {
+			continue
+		}
+
+		// attached ops immediately follow their element, greatest first
+		var deleted, assigned bool
+		for {
+			peek, err := o.nextRow(token)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return ValueToken{}, err
+			}
+			if !isAttached(peek.OpType) {
+				break
+			}
+			token = peek
+
+			switch {
+			case o.isDelete(peek.OpType):
+				deleted = true
+			case !assigned:
+				next.Value, assigned = peek.Value, true
+			}
+		}
+		if deleted {
+			continue
+		}
+
+		return next, nil
+	}
+}
+
+func (o *Object) isDelete(opType int64) bool {
+	return o.options.IsDelete != nil && o.options.IsDelete(opType)
+}
+
+// isAttached returns true if ops of the type are stored after the element they reference
+// rather than forming elements of their own
+func (o *Object) isAttached(opType int64) bool {
+	return o.options.IsAttached != nil && o.options.IsAttached(opType)
+}
+
+// findInsertLocation returns the location immediately after which op should be inserted
+// given ref, the location of the element op references.
+//
+// Concurrent inserts that reference the same element are ordered using the RGA rule: ops
+// following ref whose id is greater than the incoming id are skipped.  Those ops were
+// either inserted concurrently after ref and take priority or descend from such an op.
+// Because the rule depends only on ids, all replicas converge regardless of delivery order.
+//
+// Deletes and assigns are attached to the element they reference so inserts always skip
+// over them.  Attached ops themselves skip only the greater ops already attached to the
+// same element.
+func (o *Object) findInsertLocation(ref location, op Op) (location, error) {
+	var (
+		loc        = ref
+		start      = ref.OpIndex + 1
+		isAttached = o.isAttached(op.Type)
+	)
+	for pageIndex := ref.PageIndex; pageIndex < len(o.pages); pageIndex++ {
+		var (
+			page  = o.pages[pageIndex]
+			token PageToken
+			err   error
+		)
+		if start >= page.rowCount {
+			start = 0 // nothing follows ref on this page e.g. typing at the end of a page
+			continue
+		}
+		for i := int64(0); ; i++ {
+			token, err = page.Next(token)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return location{}, fmt.Errorf("unable to find insert location for (%v,%v): %w", op.ID.Counter, op.ID.Actor, err)
+			}
+			if i < start {
+				continue
+			}
+
+			rowIsAttached := o.isAttached(token.Op.Type)
+			if isAttached && !rowIsAttached {
+				return loc, nil // passed the ops attached to the referenced element
+			}
+			if isAttached == rowIsAttached && token.Op.ID.Compare(op.ID) < 0 {
+				return loc, nil
+			}
+
+			loc = location{
+				Offset:    loc.Offset + 1,
+				OpIndex:   i,
+				PageIndex: pageIndex,
+			}
+		}
+		start = 0
+	}
+	return loc, nil
+}
+
+// Apply inserts op into the object and returns its offset.  Apply fails with an error
+// wrapping ErrRefNotFound when op.Ref has not been applied.
 func (o *Object) Apply(op Op) (int64, error) {
+	offset, _, err := o.apply(op)
+	return offset, err
+}
+
+// apply inserts op and returns its offset.  applied reports whether op remains in the
+// object; an error splitting the page of op leaves op applied.
+func (o *Object) apply(op Op) (offset int64, applied bool, err error) {
 	ref, err := o.findPageIndex(op.Ref)
 	if err != nil {
-		return 0, fmt.Errorf("unable to find page with id (%v,%v): %w", op.Ref.Counter, op.Ref.Actor, err)
+		if errors.Is(err, io.EOF) {
+			err = ErrRefNotFound
+		}
+		return 0, false, fmt.Errorf("unable to find page with id (%v,%v): %w", op.Ref.Counter, op.Ref.Actor, err)
+	}
+	if err := o.checkTarget(op, ref); err != nil {
+		return 0, false, err
 	}
 
-	page := o.pages[ref.PageIndex]
+	prev, err := o.findInsertLocation(ref, op)
+	if err != nil {
+		return 0, false, err
+	}
 
-	if err := page.InsertAt(ref.OpIndex+1, op); err != nil {
-		return 0, err
+	page := o.pages[prev.PageIndex]
+
+	if err := page.InsertAt(prev.OpIndex+1, op); err != nil {
+		return 0, false, err
 	}
 
 	key := makeBloomKey(op.ID.Counter, op.ID.Actor)
 	defer key.Free()
-	filter := o.filters[ref.PageIndex]
+	filter := o.filters[prev.PageIndex]
 	filter.Add(key.data)
 
 	loc := location{
-		Offset:    ref.Offset + 1,
-		OpIndex:   ref.OpIndex + 1,
-		PageIndex: ref.PageIndex,
+		Offset:    prev.Offset + 1,
+		OpIndex:   prev.OpIndex + 1,
+		PageIndex: prev.PageIndex,
 	}
 
+	weight := o.tree.leaf(prev.PageIndex).weight
+	switch {
+	case o.isDelete(op.Type):
+		// deletes share a page with their target; recount as the target may already be deleted
+		if weight, err = page.Visible(o.options.IsAttached, o.options.IsDelete); err != nil {
+			return 0, true, err
+		}
+	case !o.isAttached(op.Type):
+		weight++
+	}
+	o.tree.set(prev.PageIndex, weight, page.rowCount)
+	if o.index != nil {
+		o.index.Add(op.ID, o.tree.leaf(prev.PageIndex))
+	}
+	o.clock.Observe(op.ID)
+
 	o.last.Filter = filter
-	o.last.FilterOffset = ref.Offset - ref.OpIndex
+	o.last.FilterOffset = prev.Offset - prev.OpIndex
 	o.last.ID = op.ID
 	o.last.Location = loc
 	o.last.Ok = true
 
-	if page.rowCount >= o.options.MaxPageSize {
+	if o.full(page) {
 		// when pages exceed optimal size, split them in half.  splitting the pages in half will
 		// require recalculating the bloom filter for each of the resulting pages.
 		// todo - consider algorithms to split on other boundaries
 
 		splitAtIndex := o.options.MaxPageSize / 2
-		if err := o.splitPageAt(ref.PageIndex, splitAtIndex); err != nil {
-			return 0, err
+		if err := o.splitPageAt(prev.PageIndex, splitAtIndex); err != nil {
+			return 0, true, err
 		}
 
 		o.last.Ok = false // things got rearranged after page split
+
+		// splits around deletes may leave small pages; merge them with their neighbours
+		if err := o.compact(prev.PageIndex-1, prev.PageIndex+2); err != nil {
+			return 0, true, err
+		}
 	}
 
-	return loc.Offset, nil
+	return loc.Offset, true, nil
 }
 
-func (o *Object) RowCount() (n int64) {
-	for _, p := range o.pages {
-		n += p.rowCount
+// ApplyBatch applies ops in order.  Runs of inserts in which each op references the op
+// before it, as produced by typing, are located once and inserted into the page together;
+// the page is split, if required, once the run has been inserted.  Other ops are applied
+// individually.  ApplyBatch returns the number of ops applied.  If an op fails, the ops
+// preceding it remain applied; as does the op, or its run, if the failure occurred while
+// splitting its page.
+func (o *Object) ApplyBatch(ops []Op) (int, error) {
+	var applied int
+	for len(ops) > 0 {
+		n := 1
+		if !o.isAttached(ops[0].Type) {
+			for n < len(ops) && o.continuesRun(ops[n-1], ops[n]) {
+				n++
+			}
+		}
+
+		var ok bool
+		var err error
+		if n == 1 {
+			_, ok, err = o.apply(ops[0])
+		} else {
+			ok, err = o.applyRun(ops[:n])
+		}
+		if ok {
+			applied += n
+		}
+		if err != nil {
+			return applied, err
+		}
+		ops = ops[n:]
+	}
+	return applied, nil
+}
+
+// continuesRun returns true if op may be inserted immediately after prev.  As prev was just
+// inserted, any row following it is an insert less than prev and so also less than op.
+func (o *Object) continuesRun(prev, op Op) bool {
+	return !o.isAttached(op.Type) && op.Ref.Equal(prev.ID) && op.ID.Compare(prev.ID) > 0
+}
+
+// applyRun inserts ops, a run as defined by continuesRun, at the location of the first op.
+// As with apply, applied reports whether the ops remain in the object.
+func (o *Object) applyRun(ops []Op) (applied bool, err error) {
+	first := ops[0]
+	ref, err := o.findPageIndex(first.Ref)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = ErrRefNotFound
+		}
+		return false, fmt.Errorf("unable to find page with id (%v,%v): %w", first.Ref.Counter, first.Ref.Actor, err)
+	}
+
+	prev, err := o.findInsertLocation(ref, first)
+	if err != nil {
+		return false, err
+	}
+
+	page := o.pages[prev.PageIndex]
+	if err := page.InsertOpsAt(prev.OpIndex+1, ops...); err != nil {
+		return false, err
+	}
+
+	filter, leaf := o.filters[prev.PageIndex], o.tree.leaf(prev.PageIndex)
+	for _, op := range ops {
+		key := makeBloomKey(op.ID.Counter, op.ID.Actor)
+		filter.Add(key.data)
+		key.Free()
+
+		if o.index != nil {
+			o.index.Add(op.ID, leaf)
+		}
+		o.clock.Observe(op.ID)
+	}
+
+	n := int64(len(ops))
+	o.tree.set(prev.PageIndex, o.tree.leaf(prev.PageIndex).weight+n, page.rowCount)
+
+	last := ops[len(ops)-1]
+	o.last.Filter = filter
+	o.last.FilterOffset = prev.Offset - prev.OpIndex
+	o.last.ID = last.ID
+	o.last.Location = location{
+		Offset:    prev.Offset + n,
+		OpIndex:   prev.OpIndex + n,
+		PageIndex: prev.PageIndex,
+	}
+	o.last.Ok = true
+
+	// split repeatedly as a long run may fill many pages
+	pageIndex := prev.PageIndex
+	for ; o.full(o.pages[pageIndex]); pageIndex++ {
+		pages := len(o.pages)
+		if err := o.splitPageAt(pageIndex, o.options.MaxPageSize/2); err != nil {
+			return true, err
+		}
+		o.last.Ok = false // things got rearranged after page split
+		if len(o.pages) == pages {
+			break // page could not be split
+		}
 	}
-	return
+	if pageIndex > prev.PageIndex {
+		return true, o.compact(prev.PageIndex-1, pageIndex+1)
+	}
+
+	return true, nil
+}
+
+// Clock returns the largest counter applied from each actor
+func (o *Object) Clock() Clock {
+	return o.clock.Copy()
+}
+
+// Covers returns true if the object has applied an op from id.Actor with a counter at
+// least as large as id.Counter.  Covers does not guarantee the op identified by id itself
+// was applied to this object as counters may be shared by many objects.
+func (o *Object) Covers(id ID) bool {
+	return o.clock.Covers(id)
+}
+
+// Merge applies the ops held by other that o does not hold.  Ops are applied in id order
+// which guarantees each op is applied after the op it references.  Merge is idempotent and,
+// as concurrent ops are ordered by id, replicas converge regardless of merge direction.
+func (o *Object) Merge(other *Object) error {
+	var missing []Op
+	var token OpToken
+	var err error
+	for {
+		token, err = other.NextOp(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return fmt.Errorf("unable to merge object: %w", err)
+		}
+
+		ok, err := o.contains(token.Op.ID)
+		if err != nil {
+			return fmt.Errorf("unable to merge object: %w", err)
+		}
+		if !ok {
+			missing = append(missing, copyOp(token.Op))
+		}
+	}
+
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].ID.Compare(missing[j].ID) < 0
+	})
+	for _, op := range missing {
+		if _, err := o.Apply(op); err != nil {
+			return fmt.Errorf("unable to merge op (%v,%v): %w", op.ID.Counter, op.ID.Actor, err)
+		}
+	}
+	return nil
+}
+
+// checkTarget returns an error wrapping ErrInvalidRef if op is attached, e.g. a delete, and
+// ref, the location of op.Ref, is not an element; either the start of the object or
+// another attached op
+func (o *Object) checkTarget(op Op, ref location) error {
+	if !o.isAttached(op.Type) {
+		return nil
+	}
+	if ref.OpIndex < 0 {
+		return fmt.Errorf("unable to apply op (%v,%v): no element to attach to: %w", op.ID.Counter, op.ID.Actor, ErrInvalidRef)
+	}
+
+	opType, err := o.pages[ref.PageIndex].opType.Get(ref.OpIndex)
+	if err != nil {
+		return fmt.Errorf("unable to apply op (%v,%v): %w", op.ID.Counter, op.ID.Actor, err)
+	}
+	if o.isAttached(opType) {
+		return fmt.Errorf("unable to apply op (%v,%v): ref (%v,%v) is not an element: %w", op.ID.Counter, op.ID.Actor, op.Ref.Counter, op.Ref.Actor, ErrInvalidRef)
+	}
+	return nil
+}
+
+// validateRef returns an error wrapping ErrRefNotFound if op.Ref has not been applied or
+// ErrInvalidRef if op may not reference it
+func (o *Object) validateRef(op Op) error {
+	ref, err := o.findPageIndex(op.Ref)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			err = ErrRefNotFound
+		}
+		return fmt.Errorf("unable to find ref (%v,%v): %w", op.Ref.Counter, op.Ref.Actor, err)
+	}
+	return o.checkTarget(op, ref)
+}
+
+// contains returns true if the op identified by id has been applied
+func (o *Object) contains(id ID) (bool, error) {
+	if _, err := o.findPageIndex(id); err != nil {
+		if errors.Is(err, io.EOF) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// copyOp returns a copy of op that does not share byte slices with the page it was read
+// from
+func copyOp(op Op) Op {
+	op.ID.Actor = append([]byte(nil), op.ID.Actor...)
+	op.Ref.Actor = append([]byte(nil), op.Ref.Actor...)
+	if op.Value.Bytes != nil {
+		op.Value.Bytes = append([]byte(nil), op.Value.Bytes...)
+	}
+	return op
+}
+
+func (o *Object) RowCount() int64 {
+	return o.tree.rows
 }
 
 func (o *Object) Size() int {
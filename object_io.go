@@ -0,0 +1,252 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/savaki/automerge/encoding"
+	"github.com/willf/bloom"
+)
+
+const (
+	// objectVersion identifies the format written by Object.WriteTo
+	objectVersion byte = 1
+
+	// objectFlagFilters indicates bloom filters were persisted alongside each page
+	objectFlagFilters byte = 1 << 0
+
+	// maxPreallocatedPages bounds the capacity reserved from the page count of a stream
+	maxPreallocatedPages = 1024
+)
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// WriteTo streams the object to w as:
+// * version byte
+// * raw type of the value column
+// * flags byte
+// * var int page count
+// * for each page, a var int length followed by the encoded page and, when
+// filters are persisted, a var int length followed by the encoded bloom filter
+func (o *Object) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+
+	var flags byte
+	if o.options.PersistFilters {
+		flags |= objectFlagFilters
+	}
+
+	if _, err := cw.Write([]byte{objectVersion, byte(o.rawType), flags}); err != nil {
+		return cw.n, fmt.Errorf("unable to write object header: %w", err)
+	}
+	if err := writeUvarint(cw, uint64(len(o.pages))); err != nil {
+		return cw.n, fmt.Errorf("unable to write object page count: %w", err)
+	}
+
+	var buf bytes.Buffer
+	for i, page := range o.pages {
+		data, err := page.MarshalBinary()
+		if err != nil {
+			return cw.n, fmt.Errorf("unable to marshal page, %v: %w", i, err)
+		}
+		if err := writeBytes(cw, data); err != nil {
+			return cw.n, fmt.Errorf("unable to write page, %v: %w", i, err)
+		}
+
+		if flags&objectFlagFilters == 0 {
+			continue
+		}
+
+		buf.Reset()
+		if _, err := o.filters[i].WriteTo(&buf); err != nil {
+			return cw.n, fmt.Errorf("unable to marshal bloom filter for page, %v: %w", i, err)
+		}
+		if err := writeBytes(cw, buf.Bytes()); err != nil {
+			return cw.n, fmt.Errorf("unable to write bloom filter for page, %v: %w", i, err)
+		}
+	}
+
+	return cw.n, nil
+}
+
+// ReadObject reads an object previously written with Object.WriteTo.  Persisted bloom
+// filters are reused when they match the bloom options provided; otherwise the filters
+// are rebuilt from the pages.  Options such as WithDeleteFunc should match those the
+// object was created with.  No data beyond the object is read from r, so objects may be
+// read one after another from the same stream.
+func ReadObject(r io.Reader, opts ...ObjectOption) (*Object, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = &byteReader{r: r}
+	}
+
+	var header [3]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, fmt.Errorf("unable to read object header: %w", err)
+	}
+	if version := header[0]; version != objectVersion {
+		return nil, fmt.Errorf("unable to read object: unsupported version, %v", version)
+	}
+	var (
+		rawType = encoding.RawType(header[1])
+		flags   = header[2]
+		options = makeObjectOptions(opts...)
+	)
+
+	n, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read object page count: %w", err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("unable to read object: no pages")
+	}
+
+	// n is read from the stream; bound the capacity reserved up front
+	size := n
+	if size > maxPreallocatedPages {
+		size = maxPreallocatedPages
+	}
+
+	obj := &Object{
+		options: options,
+		pages:   make([]*Page, 0, size),
+		filters: make([]*bloom.BloomFilter, 0, size),
+		rawType: rawType,
+		clock:   Clock{},
+	}
+
+	weights := make([]int64, 0, size)
+	rows := make([]int64, 0, size)
+	for i := uint64(0); i < n; i++ {
+		data, err := readBytes(r, br)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read page, %v: %w", i, err)
+		}
+
+		page := &Page{}
+		if err := page.UnmarshalBinary(data); err != nil {
+			return nil, fmt.Errorf("unable to read page, %v: %w", i, err)
+		}
+		if options.SkipInterval > 0 {
+			page.enableSkipIndex(options.SkipInterval)
+		}
+
+		var filter *bloom.BloomFilter
+		if flags&objectFlagFilters != 0 {
+			data, err := readBytes(r, br)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read bloom filter for page, %v: %w", i, err)
+			}
+
+			persisted, err := decodeBloomFilter(data)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read bloom filter for page, %v: %w", i, err)
+			}
+			if persisted.Cap() == options.Bloom.M && persisted.K() == options.Bloom.K {
+				filter = persisted
+			}
+		}
+		if filter == nil {
+			filter, err = makeBloomFilter(options.Bloom, page)
+			if err != nil {
+				return nil, fmt.Errorf("unable to rebuild bloom filter for page, %v: %w", i, err)
+			}
+		}
+
+		visible, err := page.Visible(options.IsAttached, options.IsDelete)
+		if err != nil {
+			return nil, fmt.Errorf("unable to count elements in page, %v: %w", i, err)
+		}
+
+		if err := obj.clock.observePage(page); err != nil {
+			return nil, fmt.Errorf("unable to read ids from page, %v: %w", i, err)
+		}
+
+		obj.pages = append(obj.pages, page)
+		obj.filters = append(obj.filters, filter)
+		weights = append(weights, visible)
+		rows = append(rows, page.rowCount)
+	}
+	obj.tree = buildTree(weights, rows)
+
+	if options.IDIndex {
+		obj.index = newIDIndex()
+		for i, page := range obj.pages {
+			if err := obj.index.addPage(obj.tree.leaf(i), page); err != nil {
+				return nil, fmt.Errorf("unable to index page, %v: %w", i, err)
+			}
+		}
+	}
+
+	// pages written with a smaller maximum page size may be merged
+	if options.MinPageSize > 0 {
+		if err := obj.Compact(); err != nil {
+			return nil, fmt.Errorf("unable to read object: %w", err)
+		}
+	}
+
+	return obj, nil
+}
+
+func writeUvarint(w io.Writer, v uint64) error {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	_, err := w.Write(buf[:n])
+	return err
+}
+
+func writeBytes(w io.Writer, data []byte) error {
+	if err := writeUvarint(w, uint64(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readBytes reads a var int length followed by that many bytes.  The buffer grows as
+// bytes arrive rather than trusting the length up front so that a corrupt or hostile
+// length fails with io.ErrUnexpectedEOF instead of forcing a large allocation.
+func readBytes(r io.Reader, br io.ByteReader) ([]byte, error) {
+	length, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if length > math.MaxInt64 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(length)); err != nil {
+		if err == io.EOF {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+func TestObject_WriteTo(t *testing.T) {
+	const n = 1000
+
+	testCases := map[string][]ObjectOption{
+		"rebuild filters": {WithMaxPageSize(100)},
+		"persist filters": {WithMaxPageSize(100), WithPersistedFilters()},
+		"id index":        {WithMaxPageSize(100), WithIDIndex()},
+	}
+
+	for label, opts := range testCases {
+		t.Run(label, func(t *testing.T) {
+			actor := []byte("me")
+			obj := NewObject(encoding.RawTypeVarInt, opts...)
+			for i := int64(0); i < n; i++ {
+				refActor := actor
+				if i == 0 {
+					refActor = nil
+				}
+				op := Op{
+					ID:    NewID(i+1, actor),
+					Ref:   NewID(i, refActor),
+					Value: encoding.RuneValue('a' + rune(i%26)),
+				}
+				if _, err := obj.Apply(op); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+			}
+
+			buf := bytes.NewBuffer(nil)
+			written, err := obj.WriteTo(buf)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if want, got := int64(buf.Len()), written; got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+
+			got, err := ReadObject(buf, opts...)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			if want, got := len(obj.pages), len(got.pages); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if want, got := obj.RowCount(), got.RowCount(); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if want, got := string(readAllRunes(t, obj)), string(readAllRunes(t, got)); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			for i := range obj.filters {
+				if !obj.filters[i].Equal(got.filters[i]) {
+					t.Fatalf("got filter %v not equal", i)
+				}
+			}
+
+			// loaded objects should continue to accept edits
+			op := Op{
+				ID:    NewID(n+1, actor),
+				Ref:   NewID(n, actor),
+				Value: encoding.RuneValue('!'),
+			}
+			if _, err := got.Apply(op); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if want, got := obj.RowCount()+1, got.RowCount(); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestReadObject(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		if _, err := NewObject(encoding.RawTypeVarInt).WriteTo(buf); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		obj, err := ReadObject(buf)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := int64(0), obj.RowCount(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("oversized length", func(t *testing.T) {
+		// header, one page, then a page length of ~1<<62 with no page data
+		data := []byte{objectVersion, byte(encoding.RawTypeVarInt), 0, 1}
+		data = append(data, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x40)
+		if _, err := ReadObject(bytes.NewReader(data)); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+
+	t.Run("oversized page count", func(t *testing.T) {
+		data := []byte{objectVersion, byte(encoding.RawTypeVarInt), 0}
+		data = append(data, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f)
+		if _, err := ReadObject(bytes.NewReader(data)); !errors.Is(err, io.EOF) {
+			t.Fatalf("got %v; want %v", err, io.EOF)
+		}
+	})
+
+	t.Run("consecutive", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		for _, r := range "ab" {
+			obj := NewObject(encoding.RawTypeVarInt)
+			if _, err := obj.Apply(Op{ID: NewID(1, []byte("me")), Value: encoding.RuneValue(r)}); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if _, err := obj.WriteTo(buf); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+
+		// a plain io.Reader, not an io.ByteReader
+		r := struct{ io.Reader }{buf}
+		for _, want := range "ab" {
+			obj, err := ReadObject(r)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if got := string(readAllRunes(t, obj)); got != string(want) {
+				t.Fatalf("got %v, want %v", got, string(want))
+			}
+		}
+	})
+
+	t.Run("oversized bloom filter", func(t *testing.T) {
+		page, err := NewPage(encoding.RawTypeVarInt).MarshalBinary()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		// a filter header claiming a bitset of 1<<40 bits with no bitset following
+		filter := make([]byte, 24)
+		binary.BigEndian.PutUint64(filter, 1<<40)
+		binary.BigEndian.PutUint64(filter[8:], 3)
+		binary.BigEndian.PutUint64(filter[16:], 1<<40)
+
+		buf := bytes.NewBuffer([]byte{objectVersion, byte(encoding.RawTypeVarInt), objectFlagFilters, 1})
+		if err := writeBytes(buf, page); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := writeBytes(buf, filter); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if _, err := ReadObject(buf, WithPersistedFilters()); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		buf := bytes.NewBuffer(nil)
+		if _, err := NewObject(encoding.RawTypeVarInt).WriteTo(buf); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		data := buf.Bytes()
+		if _, err := ReadObject(bytes.NewReader(data[:len(data)-1])); err == nil {
+			t.Fatalf("got nil; want error")
+		}
+	})
+}
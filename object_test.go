@@ -15,8 +15,11 @@
 package automerge
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"testing"
 
 	"github.com/savaki/automerge/encoding"
@@ -76,6 +79,465 @@ func TestObject_NextValue(t *testing.T) {
 	}
 }
 
+func TestObject_ApplyConcurrent(t *testing.T) {
+	var (
+		base = []byte("base")
+		a    = []byte("a")
+		b    = []byte("b")
+		ops  = []Op{
+			{ID: NewID(1, base), Ref: NewID(0, nil), Value: encoding.RuneValue('h')},
+			{ID: NewID(2, a), Ref: NewID(1, base), Value: encoding.RuneValue('A')},
+			{ID: NewID(3, a), Ref: NewID(2, a), Value: encoding.RuneValue('B')},
+			{ID: NewID(2, b), Ref: NewID(1, base), Value: encoding.RuneValue('C')},
+			{ID: NewID(3, b), Ref: NewID(2, b), Value: encoding.RuneValue('D')},
+			{ID: NewID(4, a), Ref: NewID(1, base), Value: encoding.RuneValue('E')},
+		}
+		want = "hECDAB"
+	)
+
+	// applies every causally consistent ordering of ops; each must converge to the same text
+	var permute func(applied []Op, remaining []Op)
+	permute = func(applied []Op, remaining []Op) {
+		if len(remaining) == 0 {
+			for _, pageSize := range []int64{2, 3, defaultRowCount} {
+				obj := NewObject(encoding.RawTypeVarInt, WithMaxPageSize(pageSize))
+				for _, op := range applied {
+					if _, err := obj.Apply(op); err != nil {
+						t.Fatalf("got %v; want nil", err)
+					}
+				}
+				if got := string(readAllRunes(t, obj)); got != want {
+					t.Fatalf("got %v, want %v; page size %v, order %v", got, want, pageSize, applied)
+				}
+			}
+			return
+		}
+
+		for i, op := range remaining {
+			ok := op.Ref.Counter == 0
+			for _, prior := range applied {
+				ok = ok || prior.ID.Equal(op.Ref)
+			}
+			if !ok {
+				continue
+			}
+
+			next := append(append([]Op{}, remaining[:i]...), remaining[i+1:]...)
+			permute(append(append([]Op{}, applied...), op), next)
+		}
+	}
+	permute(nil, ops)
+}
+
+func TestObject_Merge(t *testing.T) {
+	a := NewText([]byte("a"), WithMaxPageSize(4))
+	b := NewText([]byte("b"), WithMaxPageSize(4))
+
+	if err := a.InsertAt(0, "hello"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.obj.Merge(a.obj); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	b.clock.Observe(a.clock.counter)
+
+	// concurrent edits on both replicas
+	if err := a.InsertAt(5, " world"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := a.DeleteAt(0, 1); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.InsertAt(5, "!"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.InsertAt(0, "oh "); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	if err := a.obj.Merge(b.obj); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.obj.Merge(a.obj); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := a.String(), b.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := "oh ello! world", a.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Run("idempotent", func(t *testing.T) {
+		rows := a.RowCount()
+		if err := a.obj.Merge(b.obj); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := rows, a.RowCount(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestObject_ApplyBatch(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(1))
+		doc  = NewDocument([]byte("me"))
+		want []rune
+	)
+	text, err := doc.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	for i := 0; i < 200; i++ {
+		switch pos := rng.Intn(len(want) + 1); {
+		case pos < len(want) && rng.Intn(3) == 0:
+			if err := text.DeleteAt(pos, 1); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:pos], want[pos+1:]...)
+		default:
+			s := []rune("abcdefghij")[:rng.Intn(10)+1]
+			if err := text.InsertAt(pos, string(s)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:pos], append(s, want[pos:]...)...)
+		}
+	}
+	ops := doc.pending[1:] // skip the op creating the text
+
+	testCases := map[string][]ObjectOption{
+		"bloom":    {WithMaxPageSize(8)},
+		"id index": {WithMaxPageSize(8), WithIDIndex()},
+	}
+	for label, opts := range testCases {
+		t.Run(label, func(t *testing.T) {
+			opts = append(opts, WithDeleteFunc(isSequenceDelete))
+			obj := NewObject(encoding.RawTypeVarInt, opts...)
+			n, err := obj.ApplyBatch(ops)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if want, got := len(ops), n; got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+
+			if want, got := string(want), string(readAllRunes(t, obj)); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if want, got := int64(len(want)), obj.Len(); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if want, got := int64(len(ops)), obj.RowCount(); got != want {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			for _, page := range obj.pages {
+				if page.rowCount >= 8 {
+					t.Fatalf("got %v; want fewer than 8 rows", page.rowCount)
+				}
+			}
+			for _, op := range ops {
+				if ok, err := obj.contains(op.ID); !ok || err != nil {
+					t.Fatalf("got %v, %v; want true, nil", ok, err)
+				}
+			}
+		})
+	}
+
+	t.Run("partial failure", func(t *testing.T) {
+		me := []byte("me")
+		ops := []Op{
+			{ID: NewID(1, me), Value: encoding.RuneValue('a')},
+			{ID: NewID(2, me), Ref: NewID(1, me), Value: encoding.RuneValue('b')},
+			{ID: NewID(3, me), Ref: NewID(2, me), Value: encoding.RuneValue('c')},
+			{ID: NewID(5, me), Ref: NewID(4, me), Value: encoding.RuneValue('e')},
+			{ID: NewID(6, me), Ref: NewID(1, me), Value: encoding.RuneValue('f')},
+		}
+
+		obj := NewObject(encoding.RawTypeVarInt, WithDeleteFunc(isSequenceDelete))
+		n, err := obj.ApplyBatch(ops)
+		if !errors.Is(err, ErrRefNotFound) {
+			t.Fatalf("got %v; want %v", err, ErrRefNotFound)
+		}
+		if want, got := 3, n; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := "abc", string(readAllRunes(t, obj)); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestObject_SkipIndex(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(2))
+		doc  = NewDocument([]byte("me"), WithSkipIndex(4), WithMaxPageSize(1000))
+		want []rune
+	)
+	text, err := doc.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	for i := 0; i < 300; i++ {
+		switch pos := rng.Intn(len(want) + 1); {
+		case pos < len(want) && rng.Intn(3) == 0:
+			if err := text.DeleteAt(pos, 1); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:pos], want[pos+1:]...)
+		default:
+			s := []rune("abcdefghij")[:rng.Intn(10)+1]
+			if err := text.InsertAt(pos, string(s)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:pos], append(s, want[pos:]...)...)
+		}
+	}
+	if want, got := string(want), string(readAllRunes(t, text.obj)); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Run("apply", func(t *testing.T) {
+		obj := NewObject(encoding.RawTypeVarInt, WithSkipIndex(4), WithMaxPageSize(64), WithDeleteFunc(isSequenceDelete))
+		for _, op := range doc.pending[1:] {
+			if _, err := obj.Apply(op); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+		if want, got := string(want), string(readAllRunes(t, obj)); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestObject_Compact(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(3))
+		doc  = NewDocument([]byte("me"))
+		want []rune
+	)
+	text, err := doc.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	for i := 0; i < 200; i++ {
+		switch pos := rng.Intn(len(want) + 1); {
+		case pos < len(want) && rng.Intn(3) == 0:
+			if err := text.DeleteAt(pos, 1); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:pos], want[pos+1:]...)
+		default:
+			s := []rune("abcdefghij")[:rng.Intn(10)+1]
+			if err := text.InsertAt(pos, string(s)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:pos], append(s, want[pos:]...)...)
+		}
+	}
+	ops := doc.pending[1:] // skip the op creating the text
+
+	verify := func(t *testing.T, obj *Object) {
+		if want, got := string(want), string(readAllRunes(t, obj)); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := int64(len(want)), obj.Len(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := len(obj.pages), obj.tree.pages; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := len(obj.pages), len(obj.filters); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		for _, op := range ops {
+			if ok, err := obj.contains(op.ID); !ok || err != nil {
+				t.Fatalf("got %v, %v; want true, nil", ok, err)
+			}
+		}
+	}
+
+	testCases := map[string][]ObjectOption{
+		"bloom":    {WithMaxPageSize(8)},
+		"id index": {WithMaxPageSize(8), WithIDIndex()},
+	}
+	for label, opts := range testCases {
+		t.Run(label, func(t *testing.T) {
+			opts = append(opts, WithDeleteFunc(isSequenceDelete))
+			obj := NewObject(encoding.RawTypeVarInt, opts...)
+			if _, err := obj.ApplyBatch(ops); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			verify(t, obj)
+
+			before := len(obj.pages)
+			obj.options.MaxPageSize = 64
+			obj.options.MinPageSize = 32
+			if err := obj.Compact(); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if got := len(obj.pages); got >= before {
+				t.Fatalf("got %v pages; want fewer than %v", got, before)
+			}
+			for i := range obj.pages {
+				if obj.mergeable(i) {
+					t.Fatalf("got mergeable page, %v; want none", i)
+				}
+			}
+			verify(t, obj)
+		})
+	}
+
+	t.Run("read", func(t *testing.T) {
+		obj := NewObject(encoding.RawTypeVarInt, WithMaxPageSize(8), WithDeleteFunc(isSequenceDelete))
+		if _, err := obj.ApplyBatch(ops); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		var buf bytes.Buffer
+		if _, err := obj.WriteTo(&buf); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		got, err := ReadObject(&buf, WithMaxPageSize(64), WithMinPageSize(32), WithIDIndex(), WithDeleteFunc(isSequenceDelete))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if len(got.pages) >= len(obj.pages) {
+			t.Fatalf("got %v pages; want fewer than %v", len(got.pages), len(obj.pages))
+		}
+		verify(t, got)
+
+		// the merged pages continue to accept inserts and splits
+		first, last := ops[0], ops[len(ops)-1]
+		for i := int64(1); i <= 100; i++ {
+			op := Op{ID: NewID(last.ID.Counter+i, last.ID.Actor), Ref: first.ID, Type: sequenceInsert, Value: encoding.RuneValue('z')}
+			if _, err := got.Apply(op); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+		if want, got := int64(len(want)+100), got.Len(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestObject_Unsplittable(t *testing.T) {
+	actor := []byte("me")
+	obj := NewObject(encoding.RawTypeVarInt, WithMaxPageSize(8), WithDeleteFunc(isSequenceDelete))
+	target := NewID(1, actor)
+	if _, err := obj.Apply(Op{ID: target, Type: sequenceInsert, Value: encoding.RuneValue('a')}); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// a single element with its deletes cannot be split
+	counter := int64(1)
+	for i := 0; i < 30; i++ {
+		counter++
+		if _, err := obj.Apply(Op{ID: NewID(counter, actor), Ref: target, Type: sequenceDelete}); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		page := obj.pages[0]
+		if want, got := 1, len(obj.pages); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if page.rowCount >= 8 && page.splitRows <= page.rowCount {
+			t.Fatalf("got split threshold %v; want > %v so the page is not rescanned", page.splitRows, page.rowCount)
+		}
+	}
+
+	// once further elements arrive, the page splits
+	ref := target
+	for i := 0; i < 20; i++ {
+		counter++
+		op := Op{ID: NewID(counter, actor), Ref: ref, Type: sequenceInsert, Value: encoding.RuneValue('b')}
+		if _, err := obj.Apply(op); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		ref = op.ID
+	}
+	if len(obj.pages) == 1 {
+		t.Fatalf("got 1 page; want split")
+	}
+	if want, got := int64(20), obj.Len(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func BenchmarkObject_Apply(b *testing.B) {
+	const n = 1e4
+
+	testCases := map[string][]ObjectOption{
+		"bloom":    nil,
+		"id index": {WithIDIndex()},
+	}
+
+	for label, opts := range testCases {
+		b.Run(label, func(b *testing.B) {
+			var (
+				rng   = rand.New(rand.NewSource(1))
+				actor = []byte("me")
+			)
+			for i := 0; i < b.N; i++ {
+				obj := NewObject(encoding.RawTypeVarInt, opts...)
+				for c := int64(1); c <= n; c++ {
+					// reference a random prior op to defeat the sequential typing fast path
+					ref := NewID(rng.Int63n(c), actor)
+					if ref.Counter == 0 {
+						ref.Actor = nil
+					}
+					op := Op{
+						ID:    NewID(c, actor),
+						Ref:   ref,
+						Value: encoding.RuneValue('a'),
+					}
+					if _, err := obj.Apply(op); err != nil {
+						b.Fatalf("got %v; want nil", err)
+					}
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkObject_ApplyBatch(b *testing.B) {
+	const n = 1e4
+
+	actor := []byte("me")
+	ops := make([]Op, 0, n)
+	for c := int64(1); c <= n; c++ {
+		ref := NewID(c-1, actor)
+		if c%100 == 1 {
+			ref = ID{} // start a new run at the start of the document every 100 ops
+		}
+		ops = append(ops, Op{
+			ID:    NewID(c, actor),
+			Ref:   ref,
+			Value: encoding.RuneValue('a'),
+		})
+	}
+
+	b.Run("apply", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			obj := NewObject(encoding.RawTypeVarInt)
+			for _, op := range ops {
+				if _, err := obj.Apply(op); err != nil {
+					b.Fatalf("got %v; want nil", err)
+				}
+			}
+		}
+	})
+
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			obj := NewObject(encoding.RawTypeVarInt)
+			if _, err := obj.ApplyBatch(ops); err != nil {
+				b.Fatalf("got %v; want nil", err)
+			}
+		}
+	})
+}
+
 func readAllRunes(t *testing.T, obj *Object) []rune {
 	var runes []rune
 	var token ValueToken
@@ -16,10 +16,22 @@ package automerge
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+
 	"github.com/savaki/automerge/encoding"
 )
 
+const (
+	// pageVersion identifies the binary format produced by Page.MarshalBinary
+	pageVersion byte = 1
+
+	// pageColumns holds the number of raw buffers written by Page.MarshalBinary
+	pageColumns = 8
+)
+
 type ID struct {
 	Counter int64
 	Actor   []byte
@@ -29,6 +41,24 @@ func (i ID) Equal(that ID) bool {
 	return i.Counter == that.Counter && bytes.Equal(i.Actor, that.Actor)
 }
 
+// String returns the id formatted as counter@actor with the actor in hex
+func (i ID) String() string {
+	return fmt.Sprintf("%v@%x", i.Counter, i.Actor)
+}
+
+// Compare orders ids by lamport timestamp; counter first with ties broken by actor.
+// The result will be 0 if i == that, -1 if i < that, and +1 if i > that.
+func (i ID) Compare(that ID) int {
+	switch {
+	case i.Counter < that.Counter:
+		return -1
+	case i.Counter > that.Counter:
+		return 1
+	default:
+		return bytes.Compare(i.Actor, that.Actor)
+	}
+}
+
 func NewID(counter int64, actor []byte) ID {
 	return ID{
 		Counter: counter,
@@ -44,7 +74,8 @@ type Page struct {
 	opType     *encoding.RLE
 	value      *encoding.Plain
 
-	rowCount int64
+	rowCount  int64
+	splitRows int64 // rows at which an unsplittable page is next considered for a split
 }
 
 type IDToken struct {
@@ -62,7 +93,11 @@ type PageValueToken struct {
 }
 
 type PageToken struct {
-	Op Op
+	idToken         IDToken
+	refCounterToken encoding.DeltaToken
+	refActorToken   encoding.DictionaryRLEToken
+	valueToken      PageValueToken
+	Op              Op
 }
 
 type Op struct {
@@ -70,6 +105,7 @@ type Op struct {
 	Ref   ID
 	Type  int64
 	Value encoding.Value
+	Obj   ID // Obj identifies the target object within a Document; it is not stored in pages
 }
 
 func NewPage(rawType encoding.RawType) *Page {
@@ -139,31 +175,170 @@ func (p *Page) NextValue(token PageValueToken) (PageValueToken, error) {
 	}, nil
 }
 
+// Next reads the next op from all columns of the page
+func (p *Page) Next(token PageToken) (PageToken, error) {
+	idToken, err := p.NextID(token.idToken)
+	if err != nil {
+		return PageToken{}, err
+	}
+
+	refCounterToken, err := p.refCounter.Next(token.refCounterToken)
+	if err != nil {
+		return PageToken{}, err
+	}
+
+	refActorToken, err := p.refActor.Next(token.refActorToken)
+	if err != nil {
+		return PageToken{}, err
+	}
+
+	valueToken, err := p.NextValue(token.valueToken)
+	if err != nil {
+		return PageToken{}, err
+	}
+
+	return PageToken{
+		idToken:         idToken,
+		refCounterToken: refCounterToken,
+		refActorToken:   refActorToken,
+		valueToken:      valueToken,
+		Op: Op{
+			ID:    NewID(idToken.Counter, idToken.Actor),
+			Ref:   NewID(refCounterToken.Value, refActorToken.Value),
+			Type:  valueToken.OpType,
+			Value: valueToken.Value,
+		},
+	}, nil
+}
+
+// InsertAt inserts op at the given index.  Ops without a value, such as deletes, store
+// the zero value of the page's raw type.
 func (p *Page) InsertAt(index int64, op Op) error {
-	if err := p.counter.InsertAt(index, op.ID.Counter); err != nil {
+	return p.InsertOpsAt(index, op)
+}
+
+// InsertOpsAt inserts ops, in order, at the given index
+func (p *Page) InsertOpsAt(index int64, ops ...Op) error {
+	if index == p.rowCount {
+		return p.appendOps(ops...)
+	}
+
+	var (
+		counters    = make([]int64, 0, len(ops))
+		actors      = make([][]byte, 0, len(ops))
+		refCounters = make([]int64, 0, len(ops))
+		refActors   = make([][]byte, 0, len(ops))
+		opTypes     = make([]int64, 0, len(ops))
+		values      = make([]encoding.Value, 0, len(ops))
+	)
+	for _, op := range ops {
+		counters = append(counters, op.ID.Counter)
+		actors = append(actors, op.ID.Actor)
+		refCounters = append(refCounters, op.Ref.Counter)
+		refActors = append(refActors, op.Ref.Actor)
+		opTypes = append(opTypes, op.Type)
+
+		value := op.Value
+		if value.RawType == encoding.RawTypeUnknown {
+			value = zeroValue(p.value.RawType())
+		}
+		values = append(values, value)
+	}
+
+	if err := p.counter.InsertAt(index, counters...); err != nil {
 		return fmt.Errorf("unable to insert op counter: %w", err)
 	}
-	if err := p.actor.InsertAt(index, op.ID.Actor); err != nil {
+	if err := p.actor.InsertAt(index, actors...); err != nil {
 		return fmt.Errorf("unable to insert op actor: %w", err)
 	}
-	if err := p.refCounter.InsertAt(index, op.Ref.Counter); err != nil {
+	if err := p.refCounter.InsertAt(index, refCounters...); err != nil {
 		return fmt.Errorf("unable to insert ref counter: %w", err)
 	}
-	if err := p.refActor.InsertAt(index, op.Ref.Actor); err != nil {
+	if err := p.refActor.InsertAt(index, refActors...); err != nil {
 		return fmt.Errorf("unable to insert ref actor: %w", err)
 	}
-	if err := p.opType.InsertAt(index, op.Type); err != nil {
+	if err := p.opType.InsertAt(index, opTypes...); err != nil {
 		return fmt.Errorf("unable to insert op type: %w", err)
 	}
-	if err := p.value.InsertAt(index, op.Value); err != nil {
-		return fmt.Errorf("unable to insert value: %w", err)
+	if err := p.value.InsertAt(index, values...); err != nil {
+		return fmt.Errorf("unable to insert values: %w", err)
 	}
 
-	p.rowCount++
+	p.rowCount += int64(len(ops))
 
 	return nil
 }
 
+// appendOps adds ops after the last row using the append fast path of each column
+func (p *Page) appendOps(ops ...Op) error {
+	for _, op := range ops {
+		if err := p.counter.Append(op.ID.Counter); err != nil {
+			return fmt.Errorf("unable to append op counter: %w", err)
+		}
+		if err := p.actor.Append(op.ID.Actor); err != nil {
+			return fmt.Errorf("unable to append op actor: %w", err)
+		}
+		if err := p.refCounter.Append(op.Ref.Counter); err != nil {
+			return fmt.Errorf("unable to append ref counter: %w", err)
+		}
+		if err := p.refActor.Append(op.Ref.Actor); err != nil {
+			return fmt.Errorf("unable to append ref actor: %w", err)
+		}
+		if err := p.opType.Append(op.Type); err != nil {
+			return fmt.Errorf("unable to append op type: %w", err)
+		}
+
+		value := op.Value
+		if value.RawType == encoding.RawTypeUnknown {
+			value = zeroValue(p.value.RawType())
+		}
+		if err := p.value.Append(value); err != nil {
+			return fmt.Errorf("unable to append value: %w", err)
+		}
+		p.rowCount++
+	}
+	return nil
+}
+
+func zeroValue(rawType encoding.RawType) encoding.Value {
+	switch rawType {
+	case encoding.RawTypeByteArray:
+		return encoding.ByteSliceValue(nil)
+	default:
+		return encoding.Int64Value(0)
+	}
+}
+
+// DeleteAt removes the row at index from every column.  DeleteAt removes only the row
+// requested; callers removing an element must also remove the deletes that follow it so
+// that the first row of a page is never a delete.
+func (p *Page) DeleteAt(index int64) error {
+	if index < 0 || index >= p.rowCount {
+		return fmt.Errorf("unable to delete row, %v: %w", index, io.ErrUnexpectedEOF)
+	}
+
+	if err := p.counter.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete row, %v: op counter delete failed: %w", index, err)
+	}
+	if err := p.actor.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete row, %v: op actor delete failed: %w", index, err)
+	}
+	if err := p.refCounter.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete row, %v: ref counter delete failed: %w", index, err)
+	}
+	if err := p.refActor.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete row, %v: ref actor delete failed: %w", index, err)
+	}
+	if err := p.opType.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete row, %v: op type delete failed: %w", index, err)
+	}
+	if err := p.value.DeleteAt(index); err != nil {
+		return fmt.Errorf("unable to delete row, %v: value delete failed: %w", index, err)
+	}
+	p.rowCount--
+	return nil
+}
+
 func (p *Page) InsertAtTranslated(index int64, op Op, isDelete func(int64) bool) error {
 	translated, err := p.opType.Translate(index, isDelete)
 	if err != nil {
@@ -229,6 +404,221 @@ func (p *Page) SplitAt(index int64) (left, right *Page, err error) {
 	return lp, rp, nil
 }
 
+// Merge returns a page holding the rows of p followed by the rows of that.  As the first
+// row of a page is never a delete, the merged page preserves that invariant.
+func (p *Page) Merge(that *Page) (*Page, error) {
+	merged := &Page{rowCount: p.rowCount + that.rowCount}
+
+	var err error
+	if merged.counter, err = p.counter.Concat(that.counter); err != nil {
+		return nil, fmt.Errorf("merge failed: op counter concat failed: %w", err)
+	}
+	if merged.actor, err = p.actor.Concat(that.actor); err != nil {
+		return nil, fmt.Errorf("merge failed: op actor concat failed: %w", err)
+	}
+	if merged.refCounter, err = p.refCounter.Concat(that.refCounter); err != nil {
+		return nil, fmt.Errorf("merge failed: ref counter concat failed: %w", err)
+	}
+	if merged.refActor, err = p.refActor.Concat(that.refActor); err != nil {
+		return nil, fmt.Errorf("merge failed: ref actor concat failed: %w", err)
+	}
+	if merged.opType, err = p.opType.Concat(that.opType); err != nil {
+		return nil, fmt.Errorf("merge failed: op type concat failed: %w", err)
+	}
+	if merged.value, err = p.value.Concat(that.value); err != nil {
+		return nil, fmt.Errorf("merge failed: value concat failed: %w", err)
+	}
+	return merged, nil
+}
+
+// Visible returns the number of elements in the page that have not been deleted.  Rows
+// for which isAttached returns true are not elements; of those, the rows for which
+// isDelete returns true tombstone the element they follow.
+func (p *Page) Visible(isAttached, isDelete func(opType int64) bool) (int64, error) {
+	if isAttached == nil {
+		return p.rowCount, nil
+	}
+
+	var (
+		visible int64
+		prev    bool // true if the element preceding this row has not been deleted
+		token   encoding.RLEToken
+		err     error
+	)
+	for {
+		token, err = p.opType.Next(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return visible, nil
+			}
+			return 0, err
+		}
+
+		switch {
+		case !isAttached(token.Value):
+			visible++
+			prev = true
+		case prev && isDelete != nil && isDelete(token.Value):
+			visible-- // element is tombstoned by the delete that follows it
+			prev = false
+		}
+	}
+}
+
+// splitIndex returns the index nearest to index at which the page can be split without
+// separating attached ops from the element they follow or 0 if there is no such index
+func (p *Page) splitIndex(index int64, isAttached func(opType int64) bool) (int64, error) {
+	if isAttached == nil {
+		return index, nil
+	}
+
+	var (
+		best  int64
+		token encoding.RLEToken
+		err   error
+	)
+	for i := int64(0); ; i++ {
+		token, err = p.opType.Next(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return best, nil
+			}
+			return 0, err
+		}
+
+		if i == 0 || isAttached(token.Value) {
+			continue
+		}
+		if i >= index {
+			return i, nil
+		}
+		best = i
+	}
+}
+
+// enableSkipIndex enables a skip index with the given interval on every column.  Pages
+// split from p inherit the index.
+func (p *Page) enableSkipIndex(interval int) {
+	p.counter.EnableSkipIndex(interval)
+	p.actor.EnableSkipIndex(interval)
+	p.refCounter.EnableSkipIndex(interval)
+	p.refActor.EnableSkipIndex(interval)
+	p.opType.EnableSkipIndex(interval)
+	p.value.EnableSkipIndex(interval)
+}
+
 func (p *Page) Size() int {
 	return p.counter.Size() + p.actor.Size() + p.refCounter.Size() + p.refActor.Size() + p.opType.Size() + p.value.Size()
 }
+
+// MarshalBinary encodes the page as:
+// * version byte
+// * raw type of the value column
+// * var int row count
+// * for each column, a var int length followed by the raw column bytes
+//
+// Columns are written in order: op counter, op actor (dict, data), ref counter,
+// ref actor (dict, data), op type, value
+func (p *Page) MarshalBinary() ([]byte, error) {
+	var (
+		actorDict, actorData       = p.actor.Raw()
+		refActorDict, refActorData = p.refActor.Raw()
+		columns                    = [pageColumns][]byte{
+			p.counter.Raw(),
+			actorDict,
+			actorData,
+			p.refCounter.Raw(),
+			refActorDict,
+			refActorData,
+			p.opType.Raw(),
+			p.value.Raw(),
+		}
+	)
+
+	size := 2 + binary.MaxVarintLen64
+	for _, column := range columns {
+		size += binary.MaxVarintLen64 + len(column)
+	}
+
+	var buf [binary.MaxVarintLen64]byte
+	data := make([]byte, 0, size)
+	data = append(data, pageVersion, byte(p.value.RawType()))
+	n := binary.PutUvarint(buf[:], uint64(p.rowCount))
+	data = append(data, buf[:n]...)
+	for _, column := range columns {
+		n := binary.PutUvarint(buf[:], uint64(len(column)))
+		data = append(data, buf[:n]...)
+		data = append(data, column...)
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a page previously encoded with MarshalBinary.  The column
+// contents are copied so data may be reused by the caller.
+func (p *Page) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return fmt.Errorf("unable to unmarshal page: missing header: %w", io.ErrUnexpectedEOF)
+	}
+	if version := data[0]; version != pageVersion {
+		return fmt.Errorf("unable to unmarshal page: unsupported version, %v", version)
+	}
+	rawType := encoding.RawType(data[1])
+	if rawType == encoding.RawTypeUnknown || rawType > encoding.RawTypeCounter {
+		return fmt.Errorf("unable to unmarshal page: unknown raw type, %v", rawType)
+	}
+	pos := 2
+
+	rowCount, n := binary.Uvarint(data[pos:])
+	if n <= 0 {
+		return fmt.Errorf("unable to unmarshal page: invalid row count: %w", io.ErrUnexpectedEOF)
+	}
+	pos += n
+
+	var columns [pageColumns][]byte
+	for i := range columns {
+		length, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return fmt.Errorf("unable to unmarshal page: invalid length for column, %v: %w", i, io.ErrUnexpectedEOF)
+		}
+		pos += n
+
+		if uint64(len(data)-pos) < length {
+			return fmt.Errorf("unable to unmarshal page: column, %v, truncated: %w", i, io.ErrUnexpectedEOF)
+		}
+
+		if length > 0 {
+			column := make([]byte, length)
+			copy(column, data[pos:])
+			columns[i] = column
+		}
+		pos += int(length)
+	}
+	if pos != len(data) {
+		return fmt.Errorf("unable to unmarshal page: %v unexpected trailing bytes", len(data)-pos)
+	}
+
+	page := Page{
+		counter:    encoding.NewDelta(columns[0]),
+		actor:      encoding.NewDictionaryRLE(columns[1], columns[2]),
+		refCounter: encoding.NewDelta(columns[3]),
+		refActor:   encoding.NewDictionaryRLE(columns[4], columns[5]),
+		opType:     encoding.NewRLE(columns[6]),
+		value:      encoding.NewPlain(rawType, columns[7]),
+		rowCount:   int64(rowCount),
+	}
+
+	// every column must hold a value for each row; a corrupt column could otherwise claim
+	// far more rows than the page
+	validators := []interface{ Validate(rows int64) error }{
+		page.counter, page.actor, page.refCounter, page.refActor, page.opType, page.value,
+	}
+	for i, column := range validators {
+		if err := column.Validate(page.rowCount); err != nil {
+			return fmt.Errorf("unable to unmarshal page: column, %v: %w", i, err)
+		}
+	}
+
+	*p = page
+	return nil
+}
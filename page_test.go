@@ -15,12 +15,36 @@
 package automerge
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"github.com/savaki/automerge/encoding"
 	"io"
+	"reflect"
 	"testing"
+
+	"github.com/savaki/automerge/encoding"
 )
 
+func TestID_Compare(t *testing.T) {
+	testCases := map[string]struct {
+		a, b ID
+		want int
+	}{
+		"equal":          {a: NewID(1, []byte("a")), b: NewID(1, []byte("a")), want: 0},
+		"lower counter":  {a: NewID(1, []byte("b")), b: NewID(2, []byte("a")), want: -1},
+		"higher counter": {a: NewID(3, []byte("a")), b: NewID(2, []byte("b")), want: 1},
+		"tie on actor":   {a: NewID(2, []byte("a")), b: NewID(2, []byte("b")), want: -1},
+	}
+
+	for label, tc := range testCases {
+		t.Run(label, func(t *testing.T) {
+			if got := tc.a.Compare(tc.b); got != tc.want {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestPage_InsertAt(t *testing.T) {
 	const n = 1e3
 
@@ -98,3 +122,284 @@ func BenchmarkPage_Next(t *testing.B) {
 		}
 	}
 }
+
+func TestPage_Merge(t *testing.T) {
+	me := []byte("me")
+	you := []byte("you")
+	page := NewPage(encoding.RawTypeVarInt)
+	for i := int64(0); i < 40; i++ {
+		actor := you
+		if i%8 < 3 {
+			actor = me
+		}
+
+		op := Op{
+			ID:    NewID(i+1, actor),
+			Ref:   NewID(i, actor),
+			Type:  i % 3,
+			Value: encoding.RuneValue('a' + rune(i%26)),
+		}
+		if err := page.InsertAt(i, op); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	want := readAllPageOps(t, page)
+
+	for _, index := range []int64{0, 1, 4, 20, 39, 40} {
+		left, right, err := page.SplitAt(index)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		merged, err := left.Merge(right)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		if want, got := page.rowCount, merged.rowCount; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if got := readAllPageOps(t, merged); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestPage_DeleteAt(t *testing.T) {
+	me := []byte("me")
+	you := []byte("you")
+	page := NewPage(encoding.RawTypeVarInt)
+	for i := int64(0); i < 20; i++ {
+		actor := you
+		if i%4 == 0 {
+			actor = me
+		}
+
+		op := Op{
+			ID:    NewID(i+1, actor),
+			Ref:   NewID(i, actor),
+			Type:  i % 3,
+			Value: encoding.RuneValue('a' + rune(i)),
+		}
+		if err := page.InsertAt(i, op); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	want := readAllPageOps(t, page)
+
+	for _, index := range []int{19, 0, 7, 7} {
+		if err := page.DeleteAt(int64(index)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		want = append(want[:index], want[index+1:]...)
+
+		if want, got := int64(len(want)), page.rowCount; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if got := readAllPageOps(t, page); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	if err := page.DeleteAt(page.rowCount); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func readAllPageOps(t *testing.T, page *Page) []Op {
+	var ops []Op
+	var token PageToken
+	var err error
+	for {
+		token, err = page.Next(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return ops
+			}
+			t.Fatalf("got %v; want nil", err)
+		}
+		op := copyOp(token.Op)
+		op.Value = encoding.Int64Value(op.Value.Int)
+		ops = append(ops, op)
+	}
+}
+
+func TestPage_MarshalBinary(t *testing.T) {
+	me := []byte("me")
+	you := []byte("you")
+	page := NewPage(encoding.RawTypeVarInt)
+	for i := int64(0); i < 100; i++ {
+		actor, refActor := you, me
+		if i%8 == 0 {
+			actor, refActor = me, you
+		}
+
+		op := Op{
+			ID:    NewID(i+1, actor),
+			Ref:   NewID(i, refActor),
+			Type:  i % 3,
+			Value: encoding.RuneValue('a' + rune(i%26)),
+		}
+		if err := page.InsertAt(i, op); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+
+	data, err := page.MarshalBinary()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		var got Page
+		if err := got.UnmarshalBinary(data); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		if want, got := page.rowCount, got.rowCount; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := page.Size(), got.Size(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+
+		var wantID, gotID IDToken
+		var wantValue, gotValue PageValueToken
+		for i := int64(0); i < page.rowCount; i++ {
+			wantID, _ = page.NextID(wantID)
+			gotID, err = got.NextID(gotID)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if gotID.Counter != wantID.Counter || !bytes.Equal(gotID.Actor, wantID.Actor) {
+				t.Fatalf("got (%v,%s); want (%v,%s)", gotID.Counter, gotID.Actor, wantID.Counter, wantID.Actor)
+			}
+
+			wantValue, _ = page.NextValue(wantValue)
+			gotValue, err = got.NextValue(gotValue)
+			if err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if gotValue.OpType != wantValue.OpType || gotValue.Value.Int != wantValue.Value.Int {
+				t.Fatalf("got (%v,%v); want (%v,%v)", gotValue.OpType, gotValue.Value.Int, wantValue.OpType, wantValue.Value.Int)
+			}
+		}
+
+		op := Op{
+			ID:    NewID(101, me),
+			Ref:   NewID(100, you),
+			Value: encoding.RuneValue('z'),
+		}
+		if err := got.InsertAt(got.rowCount, op); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if index, err := got.FindIndex(101, me); err != nil || index != 100 {
+			t.Fatalf("got %v, %v; want 100, nil", index, err)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		var got Page
+		if err := got.UnmarshalBinary(data[:len(data)-1]); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("got %v; want %v", err, io.ErrUnexpectedEOF)
+		}
+	})
+
+	t.Run("unknown raw type", func(t *testing.T) {
+		for _, rawType := range []byte{0, 0xff} {
+			corrupt := append([]byte{pageVersion, rawType}, data[2:]...)
+
+			var got Page
+			if err := got.UnmarshalBinary(corrupt); err == nil {
+				t.Fatalf("got nil; want error")
+			}
+		}
+	})
+
+	t.Run("row count mismatch", func(t *testing.T) {
+		corrupt := append([]byte(nil), data...)
+		corrupt[2]-- // row count of 100 encodes as a single byte
+
+		var got Page
+		if err := got.UnmarshalBinary(corrupt); err == nil {
+			t.Fatalf("got nil; want error")
+		}
+	})
+
+	t.Run("corrupt byte", func(t *testing.T) {
+		for i := range data {
+			corrupt := append([]byte(nil), data...)
+			corrupt[i] ^= 0xff
+
+			var got Page
+			if err := got.UnmarshalBinary(corrupt); err != nil {
+				continue
+			}
+
+			// pages that decode must hold exactly rowCount ids
+			var rows int64
+			var token IDToken
+			for ; rows <= got.rowCount; rows++ {
+				if token, err = got.NextID(token); err != nil {
+					break
+				}
+			}
+			if rows != got.rowCount || !errors.Is(err, io.EOF) {
+				t.Fatalf("byte %v: got %v rows, %v; want %v rows, %v", i, rows, err, got.rowCount, io.EOF)
+			}
+		}
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		corrupt := append([]byte{pageVersion + 1}, data[1:]...)
+
+		var got Page
+		if err := got.UnmarshalBinary(corrupt); err == nil {
+			t.Fatalf("got nil; want error")
+		}
+	})
+}
+
+func BenchmarkPage_InsertOpsAt(b *testing.B) {
+	const n, run = 1e3, 100
+
+	var (
+		me   = []byte("me")
+		you  = []byte("you")
+		base = make([]Op, 0, n)
+		ops  = make([]Op, 0, run)
+	)
+	for i := int64(0); i < n; i++ {
+		base = append(base, Op{ID: NewID(i+1, you), Ref: NewID(i, you), Value: encoding.RuneValue('a')})
+	}
+	for i := int64(0); i < run; i++ {
+		ops = append(ops, Op{ID: NewID(n+i+1, me), Ref: NewID(n+i, me), Value: encoding.RuneValue('b')})
+	}
+
+	newPage := func() *Page {
+		page := NewPage(encoding.RawTypeVarInt)
+		if err := page.InsertOpsAt(0, base...); err != nil {
+			b.Fatalf("got %v; want nil", err)
+		}
+		return page
+	}
+
+	b.Run("insert at", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			page := newPage()
+			for j, op := range ops {
+				if err := page.InsertAt(n/2+int64(j), op); err != nil {
+					b.Fatalf("got %v; want nil", err)
+				}
+			}
+		}
+	})
+
+	b.Run("insert ops at", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			page := newPage()
+			if err := page.InsertOpsAt(n/2, ops...); err != nil {
+				b.Fatalf("got %v; want nil", err)
+			}
+		}
+	})
+}
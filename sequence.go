@@ -0,0 +1,167 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+const (
+	sequenceInsert = 0
+	sequenceDelete = 1
+)
+
+func isSequenceDelete(opType int64) bool {
+	return opType == sequenceDelete
+}
+
+// sequence holds the machinery shared by ordered objects such as Text and List.  Each
+// element is inserted after the element that preceded it and removed by a delete that
+// tombstones it.
+type sequence struct {
+	id     ID // id of the sequence within a document
+	clock  *lamport
+	obj    *Object
+	record func(op Op) // record, when set, receives each local op once applied
+}
+
+func newSequence(clock *lamport, id ID, rawType encoding.RawType, opts ...ObjectOption) *sequence {
+	opts = append(opts, WithDeleteFunc(isSequenceDelete))
+	return &sequence{
+		id:    id,
+		clock: clock,
+		obj:   NewObject(rawType, opts...),
+	}
+}
+
+// Apply applies an op, local or remote, to the sequence
+func (s *sequence) Apply(op Op) error {
+	if _, err := s.obj.Apply(op); err != nil {
+		return err
+	}
+	s.clock.Observe(op.ID.Counter)
+	return nil
+}
+
+// applyLocal applies an op generated by the local actor
+func (s *sequence) applyLocal(op Op) error {
+	if err := s.Apply(op); err != nil {
+		return err
+	}
+	if s.record != nil {
+		s.record(op)
+	}
+	return nil
+}
+
+// insertAt inserts values before the visible element at pos
+func (s *sequence) insertAt(pos int, values ...encoding.Value) error {
+	if pos < 0 || pos > s.Len() {
+		return fmt.Errorf("unable to insert at %v: %w", pos, io.EOF)
+	}
+
+	var ref ID // start of document
+	if pos > 0 {
+		id, err := s.obj.IDAt(int64(pos - 1))
+		if err != nil {
+			return fmt.Errorf("unable to insert at %v: %w", pos, err)
+		}
+		ref = id
+	}
+
+	ops := make([]Op, 0, len(values))
+	for _, value := range values {
+		op := Op{
+			ID:    s.clock.Next(),
+			Ref:   ref,
+			Type:  sequenceInsert,
+			Value: value,
+			Obj:   s.id,
+		}
+		ops = append(ops, op)
+		ref = op.ID
+	}
+
+	// record the ops applied even if the batch fails part way
+	n, err := s.obj.ApplyBatch(ops)
+	if s.record != nil {
+		for _, op := range ops[:n] {
+			s.record(op)
+		}
+	}
+	return err
+}
+
+// delete tombstones the element identified by target
+func (s *sequence) delete(target ID) error {
+	return s.applyLocal(Op{
+		ID:   s.clock.Next(),
+		Ref:  target,
+		Type: sequenceDelete,
+		Obj:  s.id,
+	})
+}
+
+// deleteAt deletes n visible elements starting at pos.  The range is checked up front so
+// that a failed call deletes nothing.
+func (s *sequence) deleteAt(pos, n int) error {
+	if pos < 0 || n < 0 || pos+n > s.Len() {
+		return fmt.Errorf("unable to delete %v at %v: %w", n, pos, io.EOF)
+	}
+
+	for i := 0; i < n; i++ {
+		target, err := s.obj.IDAt(int64(pos))
+		if err != nil {
+			return fmt.Errorf("unable to delete at %v: %w", pos, err)
+		}
+		if err := s.delete(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEach calls fn with the value of each visible element
+func (s *sequence) forEach(fn func(v encoding.Value)) error {
+	var token ValueToken
+	var err error
+	for {
+		token, err = s.obj.NextValue(token)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		fn(token.Value)
+	}
+}
+
+// Len returns the number of visible elements
+func (s *sequence) Len() int {
+	return int(s.obj.Len())
+}
+
+func (s *sequence) RowCount() int64 {
+	return s.obj.RowCount()
+}
+
+func (s *sequence) Size() int {
+	return s.obj.Size()
+}
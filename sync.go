@@ -0,0 +1,426 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/willf/bloom"
+)
+
+// sync message types
+const (
+	syncHave    byte = 1 // heads and a bloom filter summarising the changes held
+	syncChanges byte = 2 // changes the peer is missing
+	syncNeed    byte = 3 // hashes of changes that held changes are waiting on
+	syncOps     byte = 4 // ops applied outside of any change
+)
+
+// syncFalsePositiveRate is the false positive rate of the bloom filter sent by Sync
+const syncFalsePositiveRate = 0.01
+
+// Sync brings d and a peer into agreement by exchanging only the changes each is missing.
+// Both peers call Sync concurrently with opposite ends of the same connection, e.g.
+// net.Pipe.
+//
+// Sync proceeds in lockstep rounds in which both peers send then receive a message of the
+// same type:
+// * have: the heads of the document and a bloom filter over the hashes of its changes
+// * changes: the changes the peer lacks according to its have message
+// * need: the hashes of changes held changes wait on and of peer heads not yet received
+// * ops: the ops applied outside of any change
+//
+// A change hidden from the peer by a bloom filter false positive is requested by a need
+// message, either as a head of the peer or once a change depending on it arrives.  Rounds
+// of changes and need repeat until neither peer needs anything or a round exchanges no
+// changes.  Changes are applied with ApplyChange so the heads of both peers agree once
+// Sync returns.  As with Merge, ops outside of any change, uncommitted local ops and
+// remote ops applied with Apply, are then exchanged and applied individually; they are
+// sent in full on every Sync until committed.
+//
+// When Sync fails it unblocks its pending write, and so the peer, by expiring the write
+// deadline of rw or, failing that, closing rw.  rw must therefore implement either
+// SetWriteDeadline, as net.Conn does, or io.Closer.  Otherwise a failure on one side may
+// leave both peers blocked.
+func (d *Document) Sync(rw io.ReadWriter) error {
+	conn := syncConn{rw: rw, br: &byteReader{r: rw}}
+
+	have, err := d.encodeHave()
+	if err != nil {
+		return fmt.Errorf("unable to sync: %w", err)
+	}
+	data, err := conn.exchange(syncHave, have)
+	if err != nil {
+		return fmt.Errorf("unable to sync: %w", err)
+	}
+	heads, filter, err := decodeHave(data)
+	if err != nil {
+		return fmt.Errorf("unable to sync: %w", err)
+	}
+	send := d.changesMissingFrom(heads, filter)
+
+	for round := 0; ; round++ {
+		data, err := encodeChanges(send)
+		if err != nil {
+			return fmt.Errorf("unable to sync: %w", err)
+		}
+		if data, err = conn.exchange(syncChanges, data); err != nil {
+			return fmt.Errorf("unable to sync: %w", err)
+		}
+		received, err := decodeChanges(data)
+		if err != nil {
+			return fmt.Errorf("unable to sync: %w", err)
+		}
+		for _, change := range received {
+			if err := d.ApplyChange(change); err != nil {
+				return fmt.Errorf("unable to sync: %w", err)
+			}
+		}
+
+		missing := d.needed(heads)
+		if data, err = conn.exchange(syncNeed, encodeHashes(missing)); err != nil {
+			return fmt.Errorf("unable to sync: %w", err)
+		}
+		need, err := decodeHashes(data)
+		if err != nil {
+			return fmt.Errorf("unable to sync: %w", err)
+		}
+
+		// both peers observe the same counts so both stop in the same round
+		if len(missing) == 0 && len(need) == 0 {
+			break
+		}
+		if round > 0 && len(send) == 0 && len(received) == 0 {
+			break
+		}
+
+		send = d.changesByHash(need)
+	}
+
+	ops, err := d.opsOutsideChanges()
+	if err != nil {
+		return fmt.Errorf("unable to sync: %w", err)
+	}
+	if data, err = encodeOps(ops); err != nil {
+		return fmt.Errorf("unable to sync: %w", err)
+	}
+	if data, err = conn.exchange(syncOps, data); err != nil {
+		return fmt.Errorf("unable to sync: %w", err)
+	}
+	received, err := decodeOps(data)
+	if err != nil {
+		return fmt.Errorf("unable to sync: %w", err)
+	}
+	for _, op := range received {
+		if err := d.Apply(op); err != nil {
+			return fmt.Errorf("unable to sync: %w", err)
+		}
+	}
+	return nil
+}
+
+// objectID returns the id of a *Map, *List, or *Text
+func objectID(v interface{}) ID {
+	switch v := v.(type) {
+	case *Map:
+		return v.id
+	case *List:
+		return v.id
+	case *Text:
+		return v.id
+	default:
+		return ID{}
+	}
+}
+
+// encodeHave encodes:
+// * var int head count followed by each head
+// * bloom filter over the hashes of every change applied
+func (d *Document) encodeHave() ([]byte, error) {
+	filter := bloom.NewWithEstimates(uint(max(len(d.history), 1)), syncFalsePositiveRate)
+	for _, change := range d.history {
+		hash, err := change.Hash()
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode have message: %w", err)
+		}
+		filter.Add(hash[:])
+	}
+
+	var buf bytes.Buffer
+	if _, err := filter.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("unable to encode bloom filter: %w", err)
+	}
+
+	data := encodeHashes(d.heads)
+	return appendBytes(data, buf.Bytes()), nil
+}
+
+func decodeHave(data []byte) ([]Hash, *bloom.BloomFilter, error) {
+	r := changeReader{data: data}
+
+	heads := r.hashes()
+	filterData := r.bytes()
+	if r.err != nil {
+		return nil, nil, fmt.Errorf("unable to decode have message: %w", r.err)
+	}
+
+	filter, err := decodeBloomFilter(filterData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to decode have message: %w", err)
+	}
+	return heads, filter, nil
+}
+
+// changesMissingFrom returns, in the order applied, the changes a peer with the given heads
+// and bloom filter lacks.  Ancestors of heads are held by the peer.  Other changes are
+// sent if absent from filter or if they depend on a change being sent.
+func (d *Document) changesMissingFrom(heads []Hash, filter *bloom.BloomFilter) []*Change {
+	held := map[Hash]struct{}{}
+	for stack := append([]Hash(nil), heads...); len(stack) > 0; {
+		hash := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		change, ok := d.changes[hash]
+		if _, seen := held[hash]; seen || !ok {
+			continue
+		}
+		held[hash] = struct{}{}
+		stack = append(stack, change.Deps...)
+	}
+
+	var (
+		changes []*Change
+		sent    = map[Hash]struct{}{}
+	)
+	for _, change := range d.history {
+		hash, err := change.Hash()
+		if err != nil {
+			continue // changes in history were hashed when applied
+		}
+		if _, ok := held[hash]; ok {
+			continue
+		}
+
+		send := !filter.Test(hash[:])
+		for _, dep := range change.Deps {
+			_, ok := sent[dep]
+			send = send || ok
+		}
+		if send {
+			sent[hash] = struct{}{}
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// needed returns the hashes of the changes held changes are waiting on along with the
+// heads of a peer that d has neither applied nor holds
+func (d *Document) needed(heads []Hash) []Hash {
+	_, need := d.Missing()
+	for _, head := range heads {
+		_, applied := d.changes[head]
+		_, held := d.queue.queued[head]
+		if !applied && !held && !containsHash(need, head) {
+			need = append(need, head)
+		}
+	}
+	return need
+}
+
+// changesByHash returns, in the order applied, the changes identified by hashes that d holds
+func (d *Document) changesByHash(hashes []Hash) []*Change {
+	var changes []*Change
+	for _, change := range d.history {
+		hash, err := change.Hash()
+		if err != nil {
+			continue
+		}
+		if containsHash(hashes, hash) {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// opsOutsideChanges returns, ordered by id, the ops applied to the document that are not
+// part of an applied change
+func (d *Document) opsOutsideChanges() ([]Op, error) {
+	committed := map[string]struct{}{}
+	for _, change := range d.history {
+		for _, op := range change.Ops {
+			committed[op.ID.String()] = struct{}{}
+		}
+	}
+
+	all, err := d.ops()
+	if err != nil {
+		return nil, err
+	}
+	var ops []Op
+	for _, op := range all {
+		if _, ok := committed[op.ID.String()]; !ok {
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+func encodeOps(ops []Op) ([]byte, error) {
+	data := appendUvarint(nil, uint64(len(ops)))
+	var err error
+	for i, op := range ops {
+		if data, err = appendOp(data, op); err != nil {
+			return nil, fmt.Errorf("unable to encode op, %v: %w", i, err)
+		}
+	}
+	return data, nil
+}
+
+func decodeOps(data []byte) ([]Op, error) {
+	r := changeReader{data: data}
+
+	var ops []Op
+	for i, n := uint64(0), r.uvarint(); i < n && r.err == nil; i++ {
+		if op := r.op(); r.err == nil {
+			ops = append(ops, op)
+		}
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("unable to decode ops message: %w", r.err)
+	}
+	return ops, nil
+}
+
+func encodeChanges(changes []*Change) ([]byte, error) {
+	data := appendUvarint(nil, uint64(len(changes)))
+	for i, change := range changes {
+		encoded, err := change.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode change, %v: %w", i, err)
+		}
+		data = appendBytes(data, encoded)
+	}
+	return data, nil
+}
+
+func decodeChanges(data []byte) ([]*Change, error) {
+	r := changeReader{data: data}
+
+	var changes []*Change
+	for i, n := uint64(0), r.uvarint(); i < n && r.err == nil; i++ {
+		encoded := r.bytes()
+		if r.err != nil {
+			break
+		}
+
+		change := &Change{}
+		if err := change.UnmarshalBinary(encoded); err != nil {
+			return nil, fmt.Errorf("unable to decode change, %v: %w", i, err)
+		}
+		changes = append(changes, change)
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("unable to decode changes message: %w", r.err)
+	}
+	return changes, nil
+}
+
+func encodeHashes(hashes []Hash) []byte {
+	data := appendUvarint(nil, uint64(len(hashes)))
+	for _, hash := range hashes {
+		data = append(data, hash[:]...)
+	}
+	return data
+}
+
+func decodeHashes(data []byte) ([]Hash, error) {
+	r := changeReader{data: data}
+	hashes := r.hashes()
+	if r.err != nil {
+		return nil, fmt.Errorf("unable to decode need message: %w", r.err)
+	}
+	return hashes, nil
+}
+
+// syncConn reads and writes sync messages, each a message type byte followed by a var int
+// length and payload
+type syncConn struct {
+	rw io.ReadWriter
+	br io.ByteReader
+}
+
+// exchange sends a message while concurrently reading the peer's message of the same
+// type.  Writes happen in the background as synchronous connections, like net.Pipe, block
+// writes until the peer reads.  If the read fails, the write is aborted and waited for.
+func (c syncConn) exchange(msgType byte, payload []byte) ([]byte, error) {
+	data := appendBytes([]byte{msgType}, payload)
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := c.rw.Write(data)
+		written <- err
+	}()
+
+	got, err := c.br.ReadByte()
+	if err != nil {
+		c.abort(written)
+		return nil, fmt.Errorf("unable to read message type: %w", err)
+	}
+	if got != msgType {
+		c.abort(written)
+		return nil, fmt.Errorf("unable to read message: got type %v, want %v", got, msgType)
+	}
+	received, err := readBytes(c.rw, c.br)
+	if err != nil {
+		c.abort(written)
+		return nil, fmt.Errorf("unable to read message: %w", err)
+	}
+
+	if err := <-written; err != nil {
+		return nil, fmt.Errorf("unable to write message: %w", err)
+	}
+	return received, nil
+}
+
+// abort unblocks a pending write, by expiring the write deadline of the connection or
+// failing that closing it, then waits for the write to return
+func (c syncConn) abort(written <-chan error) {
+	if conn, ok := c.rw.(interface{ SetWriteDeadline(time.Time) error }); ok && conn.SetWriteDeadline(time.Now()) == nil {
+		<-written
+		return
+	}
+	if closer, ok := c.rw.(io.Closer); ok {
+		closer.Close()
+	}
+	<-written
+}
+
+// byteReader reads a single byte at a time so no data beyond the current message is
+// consumed from the underlying reader
+type byteReader struct {
+	r   io.Reader
+	buf [1]byte
+}
+
+func (b *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(b.r, b.buf[:]); err != nil {
+		return 0, err
+	}
+	return b.buf[0], nil
+}
@@ -0,0 +1,433 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/savaki/automerge/encoding"
+	"github.com/willf/bloom"
+)
+
+// syncDocuments commits the local edits of a and b then runs Sync between them over
+// net.Pipe
+func syncDocuments(t *testing.T, a, b *Document) {
+	for _, doc := range []*Document{a, b} {
+		if _, err := doc.Commit(""); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+	}
+	syncPipe(t, a, b)
+}
+
+// syncPipe runs Sync between a and b over net.Pipe
+func syncPipe(t *testing.T, a, b *Document) {
+	ca, cb := net.Pipe()
+	defer ca.Close()
+	defer cb.Close()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- b.Sync(cb)
+	}()
+	if err := a.Sync(ca); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+}
+
+func TestDocument_Sync(t *testing.T) {
+	a := NewDocument([]byte("a"), WithMaxPageSize(8))
+	b := NewDocument([]byte("b"), WithMaxPageSize(8))
+
+	text, err := a.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.InsertAt(0, "the quick brown fox"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	syncDocuments(t, a, b)
+
+	other, ok := b.Text(text.id)
+	if !ok {
+		t.Fatalf("got false; want true")
+	}
+	if want, got := text.String(), other.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// concurrent edits on both sides
+	if err := text.DeleteAt(4, 6); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := other.InsertAt(19, " jumps"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	list, err := b.NewList(RootID, "tags")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := list.Insert(0, encoding.StringValue("animals")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := a.Root().Set("title", encoding.StringValue("fox")); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	syncDocuments(t, a, b)
+
+	if want, got := "the brown fox jumps", text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := text.String(), other.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := a.Root().Keys(), b.Root().Keys(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, ok := a.List(list.id); !ok {
+		t.Fatalf("got false; want true")
+	}
+	if want, got := a.Clock(), b.Clock(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := a.Heads(), b.Heads(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := len(a.Changes()), len(b.Changes()); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	t.Run("in sync", func(t *testing.T) {
+		rows := text.RowCount()
+		syncDocuments(t, a, b)
+		if want, got := rows, text.RowCount(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestDocument_SyncUncommitted(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	b := NewDocument([]byte("b"))
+
+	text, err := a.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.InsertAt(0, "abc"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	syncDocuments(t, a, b)
+
+	// neither replica commits its edits
+	if err := text.InsertAt(3, "d"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	other, _ := b.Text(text.id)
+	if err := other.InsertAt(0, "z"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Root().Set("k", encoding.Int64Value(1)); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// an op applied to b outside of any change
+	c := NewDocument([]byte("c"))
+	if err := c.Merge(b); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := c.Root().Set("j", encoding.Int64Value(2)); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	change, err := c.Commit("")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.Apply(change.Ops[0]); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	syncPipe(t, a, b)
+	for _, doc := range []*Document{a, b} {
+		got, _ := doc.Text(text.id)
+		if want, got := "zabcd", got.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := []string{"body", "j", "k"}, doc.Root().Keys(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+
+	// once committed, the changes carry ops both replicas already hold
+	rows := text.RowCount()
+	syncDocuments(t, a, b)
+	if want, got := a.Heads(), b.Heads(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := text.String(), other.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := rows, text.RowCount(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+// changeFilter returns a bloom filter, large enough to avoid chance false positives, over
+// the hashes of the changes of doc along with extra
+func changeFilter(t *testing.T, doc *Document, extra ...Hash) *bloom.BloomFilter {
+	filter := bloom.New(1<<16, 8)
+	for _, change := range doc.Changes() {
+		hash, err := change.Hash()
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		filter.Add(hash[:])
+	}
+	for _, hash := range extra {
+		filter.Add(hash[:])
+	}
+	return filter
+}
+
+func TestDocument_SyncNeed(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	b := NewDocument([]byte("b"))
+
+	text, err := a.NewText(RootID, "body")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	var changes []*Change
+	for _, s := range []string{"a", "b", "c"} {
+		if err := text.InsertAt(text.Len(), s); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		change, err := a.Commit("")
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := b.ApplyChange(changes[0]); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// simulate a bloom filter false positive for the second change within b
+	second, err := changes[1].Hash()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	send := a.changesMissingFrom(b.Heads(), changeFilter(t, b, second))
+	if want, got := changes[2:], send; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if err := b.ApplyChange(send[0]); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// b holds the third change and asks for the second
+	_, need := b.Missing()
+	if want, got := []Hash{second}, need; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	syncDocuments(t, a, b)
+
+	other, _ := b.Text(text.id)
+	if want, got := "abc", other.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if _, changes := b.Pending(); len(changes) != 0 {
+		t.Fatalf("got %v; want 0", len(changes))
+	}
+	if want, got := a.Heads(), b.Heads(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDocument_SyncHeads(t *testing.T) {
+	a := NewDocument([]byte("a"))
+	b := NewDocument([]byte("b"))
+
+	var changes []*Change
+	for _, key := range []string{"x", "y"} {
+		if err := a.Root().Set(key, encoding.Int64Value(1)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		change, err := a.Commit("")
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := b.ApplyChange(changes[0]); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// simulate a bloom filter false positive for the head of a within b
+	head, err := changes[1].Hash()
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if got := a.changesMissingFrom(b.Heads(), changeFilter(t, b, head)); len(got) != 0 {
+		t.Fatalf("got %v; want none", got)
+	}
+
+	// as nothing depends on the head, b requests it as a head of a
+	need := b.needed(a.Heads())
+	if want, got := []Hash{head}, need; !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := changes[1:], a.changesByHash(need); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := a.needed(b.Heads()); len(got) != 0 {
+		t.Fatalf("got %v; want none", got)
+	}
+}
+
+// haveMessage returns a have message holding a bloom filter header, without a bitset,
+// of m, k and the bitset length
+func haveMessage(m, k, length uint64) []byte {
+	filter := make([]byte, 24)
+	binary.BigEndian.PutUint64(filter, m)
+	binary.BigEndian.PutUint64(filter[8:], k)
+	binary.BigEndian.PutUint64(filter[16:], length)
+	return appendBytes([]byte{syncHave}, appendBytes(encodeHashes(nil), filter))
+}
+
+// exchangeHave writes have while reading the have message written by the document
+func exchangeHave(conn net.Conn, have []byte) {
+	go conn.Write(have)
+
+	br := &byteReader{r: conn}
+	br.ReadByte()
+	readBytes(conn, br)
+}
+
+func TestDocument_SyncAbort(t *testing.T) {
+	testCases := map[string]func(conn net.Conn){
+		"peer closes": func(conn net.Conn) {
+			var buf [1]byte
+			io.ReadFull(conn, buf[:])
+			conn.Close()
+		},
+		"unexpected message": func(conn net.Conn) {
+			// never reads the have message written by the document
+			conn.Write([]byte{syncChanges})
+		},
+		"zero bloom m": func(conn net.Conn) {
+			exchangeHave(conn, haveMessage(0, 3, 0))
+		},
+		"oversized bloom filter": func(conn net.Conn) {
+			exchangeHave(conn, haveMessage(1<<40, 3, 1<<40))
+		},
+	}
+
+	for label, peer := range testCases {
+		t.Run(label, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+
+			doc := NewDocument([]byte("a"))
+			if err := doc.Root().Set("k", encoding.Int64Value(1)); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			if _, err := doc.Commit("k"); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+
+			ca, cb := net.Pipe()
+			defer cb.Close()
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				peer(cb)
+			}()
+			if err := doc.Sync(ca); err == nil {
+				t.Fatalf("got nil; want err")
+			}
+			<-done
+
+			// the goroutine writing to the peer must have returned
+			deadline := time.Now().Add(time.Second)
+			for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			if got := runtime.NumGoroutine(); got > before {
+				t.Fatalf("got %v goroutines, want %v", got, before)
+			}
+		})
+	}
+}
+
+// pipeConn is a connection, without deadlines, over a pair of io.Pipes
+type pipeConn struct {
+	*io.PipeReader
+	*io.PipeWriter
+}
+
+func (p pipeConn) Close() error {
+	p.PipeReader.Close()
+	return p.PipeWriter.Close()
+}
+
+func TestDocument_SyncAbortClose(t *testing.T) {
+	doc := NewDocument([]byte("a"))
+	if err := doc.Root().Set("k", encoding.Int64Value(1)); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	var (
+		ar, bw = io.Pipe()
+		br, aw = io.Pipe()
+		peer   = pipeConn{PipeReader: br, PipeWriter: bw}
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		// never reads the have message written by the document and blocks writing a
+		// message the document will not read
+		if _, err := peer.Write([]byte{syncChanges}); err != nil {
+			done <- err
+			return
+		}
+		_, err := peer.Write([]byte{syncChanges})
+		done <- err
+	}()
+
+	if err := doc.Sync(pipeConn{PipeReader: ar, PipeWriter: aw}); err == nil {
+		t.Fatalf("got nil; want err")
+	}
+
+	// closing the connection unblocks the peer
+	select {
+	case err := <-done:
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Fatalf("got %v, want %v", err, io.ErrClosedPipe)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("got timeout; want peer unblocked")
+	}
+}
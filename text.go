@@ -1,91 +1,80 @@
 package automerge
 
 import (
-	"github.com/savaki/automerge/encoding"
-)
+	"fmt"
+	"strings"
 
-const (
-	TextInsert = 0
-	TextDelete = 1
+	"github.com/savaki/automerge/encoding"
 )
 
 const (
-	defaultMaxNodeSize = 100
+	TextInsert = sequenceInsert
+	TextDelete = sequenceDelete
 )
 
-type ropeNode struct {
-	weight      int
-	offset      int
-	deletes     int
-	content     []rune
-	left, right *ropeNode
-}
-
-func (t *ropeNode) InsertAt(loc int, r rune, isDelete bool) {
-	switch {
-	case loc < t.offset:
-	}
-	if loc < t.offset {
-		t.left.InsertAt(loc, r, isDelete)
-		return
-	}
+// Text is a sequence of characters
+type Text struct {
+	*sequence
 }
 
-type Text struct {
-	maxNodeSize int
-	obj         *Object
-	tree        *ropeNode
+// NewText returns an empty Text whose local edits are attributed to actor
+func NewText(actor []byte, opts ...ObjectOption) *Text {
+	return newText(newLamport(actor), ID{}, opts...)
 }
 
-func NewText(opts ...ObjectOption) *Text {
+func newText(clock *lamport, id ID, opts ...ObjectOption) *Text {
 	return &Text{
-		maxNodeSize: defaultMaxNodeSize,
-		obj:         NewObject(encoding.RawTypeVarInt, opts...),
-		tree:        &ropeNode{},
+		sequence: newSequence(clock, id, encoding.RawTypeVarInt, opts...),
 	}
 }
 
-func (t *Text) Apply(op Op) error {
-	_, err := t.obj.Apply(op)
-	if err != nil {
-		return err
+// InsertAt inserts s before the visible character at pos
+func (t *Text) InsertAt(pos int, s string) error {
+	values := make([]encoding.Value, 0, len(s))
+	for _, r := range s {
+		values = append(values, encoding.RuneValue(r))
 	}
+	return t.insertAt(pos, values...)
+}
 
-	return err
+// Delete tombstones the character identified by target
+func (t *Text) Delete(target ID) error {
+	return t.delete(target)
 }
 
-func (t *Text) InsertAt(rr ...rune) error {
-	actor := []byte("me")
-	for i, r := range rr {
-		counter := int64(i)
-		ref := actor
-		if i == 0 {
-			ref = nil
-		}
+// DeleteAt deletes n visible characters starting at pos
+func (t *Text) DeleteAt(pos, n int) error {
+	return t.deleteAt(pos, n)
+}
 
-		op := Op{
-			ID: ID{
-				Counter: counter + 1,
-				Actor:   actor,
-			},
-			Ref: ID{
-				Counter: counter,
-				Actor:   ref,
-			},
-			Type:  TextInsert,
-			Value: encoding.RuneValue(r),
-		}
-		if err := t.Apply(op); err != nil {
-			return err
-		}
+// forEach calls fn with each visible character of the text
+func (t *Text) forEach(fn func(r rune)) error {
+	return t.sequence.forEach(func(v encoding.Value) {
+		fn(rune(v.Int))
+	})
+}
+
+// Runes returns the visible characters of the text
+func (t *Text) Runes() ([]rune, error) {
+	var rr []rune
+	if err := t.forEach(func(r rune) { rr = append(rr, r) }); err != nil {
+		return nil, fmt.Errorf("unable to read text: %w", err)
 	}
-	return nil
+	return rr, nil
 }
 
-func (t *Text) RowCount() int64 {
-	return t.obj.RowCount()
+// Value returns the visible text
+func (t *Text) Value() (string, error) {
+	var sb strings.Builder
+	if err := t.forEach(func(r rune) { sb.WriteRune(r) }); err != nil {
+		return "", fmt.Errorf("unable to read text: %w", err)
+	}
+	return sb.String(), nil
 }
 
-func (t *Text) Size() int {
-	return t.obj.Size()
+// String returns the visible text or, if the text cannot be read, an empty string.  Use
+// Value to observe the error.
+func (t *Text) String() string {
+	s, _ := t.Value()
+	return s
 }
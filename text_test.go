@@ -1,11 +1,379 @@
 package automerge
 
-import "testing"
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/savaki/automerge/encoding"
+)
 
 func TestText_Apply(t *testing.T) {
-	text := NewText()
-	err := text.InsertAt('h', 'e', 'l', 'l', 'o')
+	text := NewText([]byte("me"))
+	err := text.InsertAt(0, "hello")
+	if err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := "hello", text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestText_InsertAt(t *testing.T) {
+	t.Run("repeated inserts", func(t *testing.T) {
+		text := NewText([]byte("me"), WithMaxPageSize(4))
+		for _, edit := range []struct {
+			pos int
+			s   string
+		}{
+			{pos: 0, s: "world"},
+			{pos: 0, s: "hello "},
+			{pos: 5, s: ","},
+			{pos: 12, s: "!"},
+		} {
+			if err := text.InsertAt(edit.pos, edit.s); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+
+		if want, got := "hello, world!", text.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+		if want, got := int64(13), text.clock.counter; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		text := NewText([]byte("me"))
+		if err := text.InsertAt(0, "abc"); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := text.InsertAt(4, "d"); !errors.Is(err, io.EOF) {
+			t.Fatalf("got %v; want %v", err, io.EOF)
+		}
+		if err := text.InsertAt(-5, "Q"); !errors.Is(err, io.EOF) {
+			t.Fatalf("got %v; want %v", err, io.EOF)
+		}
+		if want, got := "abc", text.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("observes remote counters", func(t *testing.T) {
+		text := NewText([]byte("me"))
+		op := Op{
+			ID:    NewID(10, []byte("you")),
+			Type:  TextInsert,
+			Value: encoding.RuneValue('a'),
+		}
+		if err := text.Apply(op); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := text.InsertAt(1, "b"); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := int64(11), text.clock.counter; got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestText_Concurrent(t *testing.T) {
+	a := NewText([]byte("a"))
+	b := NewText([]byte("b"))
+
+	if err := a.InsertAt(0, "hello"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	exchangeOps(t, a.sequence, b.sequence)
+
+	if err := a.InsertAt(5, " world"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.InsertAt(0, "oh "); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := b.DeleteAt(3, 1); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	exchangeOps(t, a.sequence, b.sequence)
+
+	if want, got := "oh ello world", a.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := a.String(), b.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestText_Delete(t *testing.T) {
+	var (
+		me  = []byte("me")
+		you = []byte("you")
+	)
+
+	t.Run("single", func(t *testing.T) {
+		text := NewText(me)
+		if err := text.InsertAt(0, "hello"); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := text.Delete(NewID(2, me)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := "hllo", text.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("concurrent deletes", func(t *testing.T) {
+		text := NewText(me)
+		if err := text.InsertAt(0, "hello"); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := text.Delete(NewID(5, me)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := text.Apply(Op{ID: NewID(6, you), Ref: NewID(5, me), Type: TextDelete}); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if want, got := "hell", text.String(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("insert after deleted", func(t *testing.T) {
+		ops := []Op{
+			{ID: NewID(6, me), Ref: NewID(2, me), Type: TextDelete},
+			{ID: NewID(6, you), Ref: NewID(2, me), Type: TextInsert, Value: encoding.RuneValue('a')},
+			{ID: NewID(7, you), Ref: NewID(3, me), Type: TextDelete},
+		}
+
+		for _, order := range [][]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}} {
+			for _, pageSize := range []int64{2, 3, defaultRowCount} {
+				text := NewText(me, WithMaxPageSize(pageSize))
+				if err := text.InsertAt(0, "hello"); err != nil {
+					t.Fatalf("got %v; want nil", err)
+				}
+				for _, i := range order {
+					if err := text.Apply(ops[i]); err != nil {
+						t.Fatalf("got %v; want nil", err)
+					}
+				}
+				if want, got := "halo", text.String(); got != want {
+					t.Fatalf("got %v, want %v; order %v, page size %v", got, want, order, pageSize)
+				}
+			}
+		}
+	})
+
+	t.Run("missing target", func(t *testing.T) {
+		text := NewText(me)
+		if err := text.Delete(NewID(7, you)); err == nil {
+			t.Fatalf("got nil; want error")
+		}
+	})
+
+	t.Run("invalid target", func(t *testing.T) {
+		text := NewText(me)
+		if err := text.InsertAt(0, "hi"); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if err := text.Delete(NewID(1, me)); err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		// neither the start of the text nor a delete is an element
+		for _, target := range []ID{{}, NewID(3, me)} {
+			if err := text.Delete(target); !errors.Is(err, ErrInvalidRef) {
+				t.Fatalf("got %v; want %v", err, ErrInvalidRef)
+			}
+		}
+		if want, got := int64(3), text.RowCount(); got != want {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	})
+}
+
+func TestText_DeleteAt(t *testing.T) {
+	text := NewText([]byte("me"), WithMaxPageSize(4))
+	if err := text.InsertAt(0, "hello, world"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.DeleteAt(5, 7); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if want, got := "hello", text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if err := text.DeleteAt(5, 1); !errors.Is(err, io.EOF) {
+		t.Fatalf("got %v; want %v", err, io.EOF)
+	}
+
+	// a range running past the end deletes nothing
+	rows := text.RowCount()
+	for _, r := range [][2]int{{2, 100}, {-1, 2}, {0, -1}} {
+		if err := text.DeleteAt(r[0], r[1]); !errors.Is(err, io.EOF) {
+			t.Fatalf("got %v; want %v", err, io.EOF)
+		}
+	}
+	if want, got := "hello", text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := rows, text.RowCount(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestText_String(t *testing.T) {
+	text := NewText([]byte("me"))
+	if err := text.InsertAt(0, "你好 world"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+	if err := text.DeleteAt(2, 1); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	if want, got := "你好world", text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	runes, err := text.Runes()
 	if err != nil {
 		t.Fatalf("got %v; want nil", err)
 	}
+	if want, got := []rune("你好world"), runes; string(got) != string(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := 7, text.Len(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := int64(9), text.RowCount(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestText_Corrupt(t *testing.T) {
+	text := NewText([]byte("me"))
+	if err := text.InsertAt(0, "hello"); err != nil {
+		t.Fatalf("got %v; want nil", err)
+	}
+
+	// a truncated var int in the value column
+	text.obj.pages[0].value = encoding.NewPlain(encoding.RawTypeVarInt, []byte{0x80})
+
+	if _, err := text.Value(); err == nil {
+		t.Fatalf("got nil; want err")
+	}
+	if _, err := text.Runes(); err == nil {
+		t.Fatalf("got nil; want err")
+	}
+	if want, got := "", text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestText_Empty(t *testing.T) {
+	text := NewText([]byte("me"))
+	if want, got := "", text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := 0, text.Len(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got, err := text.Runes(); err != nil || len(got) != 0 {
+		t.Fatalf("got %v, %v; want empty", got, err)
+	}
+}
+
+// exchangeOps applies to each sequence the ops held only by the other
+func exchangeOps(t *testing.T, a, b *sequence) {
+	aOps, bOps := readAllOps(t, a.obj), readAllOps(t, b.obj)
+	applyMissing := func(s *sequence, have, ops []Op) {
+		var missing []Op
+		for _, op := range ops {
+			found := false
+			for _, h := range have {
+				found = found || h.ID.Equal(op.ID)
+			}
+			if !found {
+				missing = append(missing, op)
+			}
+		}
+
+		// lamport order is a valid causal order
+		sort.Slice(missing, func(i, j int) bool { return missing[i].ID.Compare(missing[j].ID) < 0 })
+		for _, op := range missing {
+			if err := s.Apply(op); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+		}
+	}
+	applyMissing(a, aOps, bOps)
+	applyMissing(b, bOps, aOps)
+}
+
+func readAllOps(t *testing.T, obj *Object) []Op {
+	var ops []Op
+	var token OpToken
+	var err error
+	for {
+		token, err = obj.NextOp(token)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+
+		ops = append(ops, copyOp(token.Op))
+	}
+	return ops
+}
+
+func TestText_RandomEdits(t *testing.T) {
+	var (
+		rng  = rand.New(rand.NewSource(1))
+		text = NewText([]byte("me"), WithMaxPageSize(8))
+		want []rune
+	)
+
+	for i := 0; i < 2000; i++ {
+		switch pos := rng.Intn(len(want) + 1); {
+		case pos < len(want) && rng.Intn(3) == 0:
+			n := rng.Intn(len(want)-pos) + 1
+			if err := text.DeleteAt(pos, n); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:pos], want[pos+n:]...)
+
+		default:
+			s := string(rune('a' + rng.Intn(26)))
+			if err := text.InsertAt(pos, s); err != nil {
+				t.Fatalf("got %v; want nil", err)
+			}
+			want = append(want[:pos], append([]rune(s), want[pos:]...)...)
+		}
+	}
+
+	if want, got := string(want), text.String(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if want, got := len(want), text.Len(); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for pos := range want {
+		id, err := text.obj.IDAt(int64(pos))
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		got, err := text.obj.Position(id)
+		if err != nil {
+			t.Fatalf("got %v; want nil", err)
+		}
+		if got != int64(pos) {
+			t.Fatalf("got %v, want %v", got, pos)
+		}
+	}
 }
@@ -0,0 +1,74 @@
+// Copyright 2020 Matt Ho
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package automerge
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/savaki/automerge/encoding"
+)
+
+// appendValue encodes v prefixed by a header byte which allows values of differing types
+// to share a byte array column.  The low 4 bits of the header hold the raw type and the
+// high 4 bits the value type set on v, e.g. utf8 for strings, or zero if none was set.
+// Values of RawTypeUnknown encode as the header alone.
+func appendValue(buffer []byte, v encoding.Value) ([]byte, error) {
+	if v.RawType >= 1<<valueHeaderBits || v.Type >= 1<<valueHeaderBits {
+		return nil, fmt.Errorf("unable to append value: invalid raw type, %v, or type, %v", v.RawType, v.Type)
+	}
+
+	buffer = append(buffer, byte(v.Type)<<valueHeaderBits|byte(v.RawType))
+	if v.RawType == encoding.RawTypeUnknown {
+		return buffer, nil
+	}
+	return v.Append(buffer)
+}
+
+// valueHeaderBits holds the number of bits of the header written by appendValue used by
+// the raw type
+const valueHeaderBits = 4
+
+// readValue decodes a value encoded by appendValue and returns the number of bytes read.
+// Byte array values are copied so they remain valid after the underlying page changes.
+func readValue(buffer []byte) (encoding.Value, int, error) {
+	if len(buffer) == 0 {
+		return encoding.Value{}, 0, fmt.Errorf("unable to read value: %w", io.ErrUnexpectedEOF)
+	}
+
+	var (
+		rawType = encoding.RawType(buffer[0] & (1<<valueHeaderBits - 1))
+		t       = encoding.ValueType(buffer[0] >> valueHeaderBits)
+	)
+	if t > encoding.ValueTypeTimestamp {
+		return encoding.Value{}, 0, fmt.Errorf("unable to read value: unknown value type, %v", t)
+	}
+	if rawType == encoding.RawTypeUnknown {
+		return encoding.Value{Type: t}, 1, nil
+	}
+
+	v, err := encoding.ReadValue(rawType, buffer[1:])
+	if err != nil {
+		return encoding.Value{}, 0, err
+	}
+	if n := v.Length(); n <= 0 || n > len(buffer)-1 {
+		return encoding.Value{}, 0, fmt.Errorf("unable to read value: %w", io.ErrUnexpectedEOF)
+	}
+	if v.Bytes != nil {
+		v.Bytes = append([]byte(nil), v.Bytes...)
+	}
+	v.Type = t
+	return v, 1 + v.Length(), nil
+}